@@ -2,6 +2,7 @@ package alog
 
 import (
 	"io"
+	"time"
 )
 
 // Level const
@@ -25,12 +26,23 @@ const (
 	WithUTC                         // WithUTC will show UTC time formats
 	WithUnixTime                    // WithUnixTime will show unix time
 	WithUnixTimeMs                  // WithUnixTimeMs will show unix time with millisecond
+	WithCaller                      // WithCaller will show caller's file and line as `dir/file.go:123`
+	WithFunc                        // WithFunc will show caller's function name as `pkg.Func`
+	WithRFC3339                     // WithRFC3339 will show time as RFC 3339 such as `2021-03-08T20:33:37+09:00`
+	WithRFC3339Nano                 // WithRFC3339Nano will show time as RFC 3339 with nanoseconds
+	WithUnixTimeUs                  // WithUnixTimeUs will show unix time with microsecond
+	WithUnixTimeNs                  // WithUnixTimeNs will show unix time with nanosecond
 
 	// UseDefault holds default output format when no option is given.
 	WithDefault = WithTime | WithDate | WithLevel | WithTag
 	// fHasTime is precalculated time for internal functions. Not that if WithUTC is used by it self,
 	// without any below, it won't print any time.
-	fHasTime = WithDate | WithDay | WithTime | WithTimeMs | WithUnixTime | WithUnixTimeMs
+	fHasTime = WithDate | WithDay | WithTime | WithTimeMs | WithUnixTime | WithUnixTimeMs |
+		WithRFC3339 | WithRFC3339Nano | WithUnixTimeUs | WithUnixTimeNs
+	// fHasTS is precalculated flags showing time as a single timestamp.
+	fHasTS = WithUnixTime | WithUnixTimeMs | WithUnixTimeUs | WithUnixTimeNs | WithRFC3339 | WithRFC3339Nano
+	// fHasCaller is precalculated caller flags for internal functions.
+	fHasCaller = WithCaller | WithFunc
 )
 
 // KeyValue const
@@ -40,6 +52,20 @@ const (
 	KvString                    // KvString indicates string type KeyValue
 	KvBool                      // KvBool indicates bool type KeyValue
 	KvError                     // KvError indicates error type KeyValue
+	KvStack                     // KvStack indicates stack trace type KeyValue; frames are in Vkvs
+	KvUint                      // KvUint indicates uint64 type KeyValue; stored in Vint
+	KvDuration                  // KvDuration indicates time.Duration type KeyValue; stored in Vint
+	KvTime                      // KvTime indicates time.Time type KeyValue; see KeyValue.Time()
+	KvBytes                     // KvBytes indicates []byte type KeyValue shown as a string
+	KvHex                       // KvHex indicates []byte type KeyValue shown as a hex string
+	KvBase64                    // KvBase64 indicates []byte type KeyValue shown as a base64 string
+	KvStrs                      // KvStrs indicates string array type KeyValue; items are in Vkvs
+	KvInts                      // KvInts indicates int64 array type KeyValue; items are in Vkvs
+	KvFloats                    // KvFloats indicates float64 array type KeyValue; items are in Vkvs
+	KvBools                     // KvBools indicates bool array type KeyValue; items are in Vkvs
+	KvErrs                      // KvErrs indicates error array type KeyValue; items are in Vkvs
+	KvDict                      // KvDict indicates nested object type KeyValue; items are in Vkvs
+	KvAny                       // KvAny indicates any type KeyValue stored in Vany; see Entry.Any
 )
 
 // New will return a Alog logger pointer with default values.
@@ -57,13 +83,50 @@ func New(w io.Writer) Logger {
 }
 
 // Logger is a main struct for Alog.
-// This struct is 80 bytes.
+// This struct is 216 bytes.
 type Logger struct {
 	//w       io.Writer
 	w       Writer
 	orFmtr  Formatter
-	Control control // 32 bytes
+	Control control // 40 bytes
 	Flag    Flag
+	bound   *bound
+	ctxFns  []CtxFn
+	hooks   []HookFn
+
+	// CallerSkip is the number of additional frames to skip
+	// when WithCaller or WithFunc is used. This is for wrappers
+	// that call Entry.Write or Entry.Writes on behalf of the user.
+	CallerSkip int
+
+	// StackLevel will attach the stack trace automatically
+	// to entries with this level or above. 0 will disable it.
+	//   eg. al.StackLevel = alog.ErrorLevel
+	StackLevel Level
+
+	// Location, if set, will be used for time of the built-in formatter
+	// unless WithUTC is used. nil will use the local time.
+	Location *time.Location
+
+	// Clock, if set, will be used to get the time of entries instead
+	// of time.Now. It's taken once per entry; see also Entry.At.
+	//   eg. al.Clock = func() time.Time { return fixedTime }
+	Clock ClockFn
+
+	// Dedup, if set, will suppress duplicated entries. See NewDedup.
+	Dedup *Dedup
+
+	sinks    []Sink
+	sinkFlag Flag    // sinkFlag is a union of flags of sinks
+	layout   *layout // layout of the built-in formatter; nil for default
+}
+
+// bound holds fields bound to a logger by Logger.With.
+// kvs are used by custom formatters, and buf is the same kvs
+// pre-encoded for the built-in formatter.
+type bound struct {
+	kvs []KeyValue
+	buf []byte
 }
 
 // NewTag will create a new tag
@@ -86,15 +149,68 @@ func (l Logger) Ext(fn LoggerFn) Logger {
 	return l
 }
 
-// Close will close io.Writer if applicable
+// With will return a new Logger with fields bound to it. Bound fields
+// are added to every Entry created from the returned logger.
+// For the built-in formatter, those fields are encoded only once here,
+// so they cost only a byte copy per log. Custom formatters will receive
+// them as leading KeyValues.
+//   eg. reqLog := al.With(func(e *alog.Entry) *alog.Entry {
+//           return e.Str("service", "api").Str("request_id", id)
+//       })
+func (l Logger) With(fn EntryFn) Logger {
+	if fn == nil {
+		return l
+	}
+	// As kvs will be kept by the logger, use a new Entry
+	// rather than one from the pool.
+	e := fn(&Entry{})
+	if e == nil || len(e.kvs) == 0 {
+		return l
+	}
+	b := &bound{}
+	if l.bound != nil {
+		b.kvs = append(b.kvs, l.bound.kvs...)
+		b.buf = append(b.buf, l.bound.buf...)
+	}
+	b.kvs = append(b.kvs, e.kvs...)
+	b.buf = dFmt.addKVs(b.buf, e.kvs)
+	l.bound = b
+	return l
+}
+
+// WithHook will return a new Logger with hooks added. Hooks run in order
+// for every loggable entry before it is formatted, for both the built-in
+// formatter and custom formatters. See HookFn.
+func (l Logger) WithHook(fns ...HookFn) Logger {
+	if len(fns) == 0 {
+		return l
+	}
+	hooks := make([]HookFn, 0, len(l.hooks)+len(fns))
+	hooks = append(hooks, l.hooks...)
+	for i := 0; i < len(fns); i++ {
+		if fns[i] != nil {
+			hooks = append(hooks, fns[i])
+		}
+	}
+	l.hooks = hooks
+	return l
+}
+
+// Close will close io.Writer if applicable, and writers of sinks.
+// It returns the first error if any.
 func (l Logger) Close() error {
+	var err error
 	if l.orFmtr != nil {
-		return l.orFmtr.Close()
+		err = l.orFmtr.Close()
+	} else if c, ok := l.w.(io.Closer); ok && c != nil {
+		err = c.Close()
 	}
-	if c, ok := l.w.(io.Closer); ok && c != nil {
-		return c.Close()
+	for i := 0; i < len(l.sinks); i++ {
+		if serr := l.sinks[i].Close(); serr != nil && err == nil {
+			err = serr
+		}
 	}
-	return nil
+	return err
 }
 
 // SetOutput will set the output writer to be used
@@ -135,11 +251,21 @@ func (l *Logger) getEntry(level Level, tags ...Tag) *Entry {
 			tag = tag | tags[i]
 		}
 	}
+	var ok bool
 	if l.Control.Fn != nil {
-		if l.Control.Fn(level, tag) == false {
-			return nil
-		}
-	} else if l.Control.Check(level, tag) == false {
+		ok = l.Control.Fn(level, tag)
+	} else {
+		ok = l.Control.Check(level, tag)
+	}
+	// Even if the logger's Control doesn't allow it,
+	// the entry is loggable when any of sinks allows it.
+	if ok == false && (len(l.sinks) == 0 || l.checkSinks(level, tag) == false) {
+		return nil
+	}
+
+	// Sampling is done only for loggable entries, and before
+	// getting an Entry from the pool.
+	if l.Control.SampleWith(level, tag, l.Clock) == false {
 		return nil
 	}
 
@@ -149,13 +275,27 @@ func (l *Logger) getEntry(level Level, tags ...Tag) *Entry {
 		tbucket: l.Control.bucket,
 		orFmtr:  l.orFmtr,
 		w:       l.w,
+		bound:   l.bound,
+		skip:    l.CallerSkip,
+		stack:   l.StackLevel,
+		ctxFns:  l.ctxFns,
+		dedup:   l.Dedup,
+		hooks:   l.hooks,
+		main:    ok,
+		sinks:   l.sinks,
+		sflag:   l.sinkFlag,
+		layout:  l.layout,
+		loc:     l.Location,
+		clock:   l.Clock,
 	}
 
 	e.tag = tag
 	e.level = level
+	e.at = time.Time{}
 
 	e.buf = e.buf[:0]
 	e.kvs = e.kvs[:0]
+	e.sub = e.sub[:0]
 	return e
 }
 
@@ -150,6 +150,74 @@ func TestLogger_SetFormatter(t *testing.T) {
 	tmp.Info(0).Str("test", "ok").Writes("done")
 	check(t, `{"level":"info","tag":[],"message":"done","test":"ok"}`)
 }
+func TestLogger_With(t *testing.T) {
+	svc := log.With(func(e *alog.Entry) *alog.Entry {
+		return e.Str("service", "api")
+	})
+	req := svc.With(func(e *alog.Entry) *alog.Entry {
+		return e.Int("request_id", 12)
+	})
+
+	req.Info(0).Str("test", "ok").Writes("done")
+	check(t, `{"level":"info","tag":[],"message":"done","service":"api","request_id":12,"test":"ok"}`)
+
+	// parent logger should not be affected
+	svc.Info(0).Writes("done")
+	check(t, `{"level":"info","tag":[],"message":"done","service":"api"}`)
+	log.Info(0).Writes("done")
+	check(t, `{"level":"info","tag":[],"message":"done"}`)
+
+	// custom formatter receives bound fields as leading KeyValues
+	req = req.Ext(ext.LogFmt.Text())
+	req.Info(0).Str("test", "ok").Writes("done")
+	check(t, `INF [] done // service="api", request_id=12, test="ok"`)
+
+	if tmp := log.With(nil); tmp.Output() != log.Output() {
+		t.Errorf("Logger.With(nil) should return the same logger")
+	}
+}
+
+func TestLogger_WithHook(t *testing.T) {
+	errCount := 0
+	tmp := log
+	log = log.With(func(e *alog.Entry) *alog.Entry {
+		return e.Str("svc", "api")
+	}).WithHook(
+		func(e *alog.Entry, level alog.Level, tag alog.Tag, msg string, kvs []alog.KeyValue) bool {
+			if level >= alog.ErrorLevel {
+				errCount++
+			}
+			// veto entries with "secret" key
+			for i := 0; i < len(kvs); i++ {
+				if kvs[i].Key == "secret" {
+					return false
+				}
+			}
+			return true
+		},
+		func(e *alog.Entry, level alog.Level, tag alog.Tag, msg string, kvs []alog.KeyValue) bool {
+			e.Str("host", "h1")
+			return true
+		})
+
+	log.Error(0).Int("a", 1).Writes("done")
+	check(t, `{"level":"error","tag":[],"message":"done","svc":"api","a":1,"host":"h1"}`)
+	log.Info(0).Str("secret", "x").Writes("done")
+	check(t, ``)
+
+	// Hooks run identically for custom formatters.
+	log = log.Ext(ext.LogFmt.Text())
+	log.Error(0).Int("a", 1).Writes("done")
+	check(t, `ERR [] done // svc="api", a=1, host="h1"`)
+	log.Info(0).Str("secret", "x").Writes("done")
+	check(t, ``)
+
+	if errCount != 2 {
+		t.Errorf("unexpected errCount: %d", errCount)
+	}
+	log = tmp
+}
+
 // func TestNew(t *testing.T) {
 // 	log = alog.New(nil)
 // 	log.Flag = alog.WithLevel | alog.WithTag
@@ -161,6 +229,53 @@ func TestLogger_SetFormatter(t *testing.T) {
 // 	log.Fatal(0).Writes("error!")
 // 	check(t, `{"level":"fatal","tag":[],"message":"error!"}`)
 // }
+func TestLogger_AddSink(t *testing.T) {
+	var main, js1, js2, txt bytes.Buffer
+	al := alog.New(&main)
+	al.Flag = alog.WithLevel
+	al.Control.Level = alog.WarnLevel
+	tagDB := al.NewTag("DB")
+
+	s1 := alog.NewSink(&js1, nil)
+	s1.Flag = alog.WithLevel
+	s2 := alog.NewSink(&js2, nil)
+	s2.Flag = alog.WithLevel
+	s2.Level = alog.DebugLevel
+	s3 := alog.NewSink(&txt, ext.NewFormatterTerminal())
+	s3.Flag = alog.WithLevel | alog.WithTag
+	s3.Level = alog.ErrorLevel
+	s3.Tags = tagDB
+	al = al.AddSink(s1, s2, s3)
+
+	al.Trace(tagDB).Writes("1") // s3 by the tag
+	al.Debug().Writes("2")      // s2
+	al.Info().Writes("3")       // s1, s2
+	al.Error().Writes("4")      // all
+
+	for _, tc := range []struct {
+		name string
+		buf  *bytes.Buffer
+		exp  string
+	}{
+		{"main", &main, `{"level":"error","message":"4"}` + "\n"},
+		{"js1", &js1, `{"level":"info","message":"3"}` + "\n" + `{"level":"error","message":"4"}` + "\n"},
+		{"js2", &js2, `{"level":"debug","message":"2"}` + "\n" + `{"level":"info","message":"3"}` + "\n" + `{"level":"error","message":"4"}` + "\n"},
+		{"txt", &txt, "TRC [DB] 1 \nERR [] 4 \n"},
+	} {
+		if tc.buf.String() != tc.exp {
+			t.Errorf("%s: unexpected output: %q", tc.name, tc.buf.String())
+		}
+	}
+
+	// Sinks of the same flag share the formatted entry.
+	al = alog.New(nil).AddSink(s1, s2)
+	if n := testing.AllocsPerRun(100, func() {
+		al.Info().Int("a", 1).Writes("ok")
+	}); n != 0 && !raceEnabled {
+		t.Errorf("unexpected allocs: %v", n)
+	}
+}
+
 func TestLogger_getEntry(t *testing.T) {
 	newFakeControlFn := func(retVal bool) alog.ControlFn {
 		return func(level alog.Level, tag alog.Tag) bool {
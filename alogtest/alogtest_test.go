@@ -0,0 +1,99 @@
+package alogtest_test
+
+import (
+	"errors"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/alogtest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTB records Log and Errorf calls.
+type fakeTB struct {
+	testing.TB
+	logs, errs []string
+}
+
+func (f *fakeTB) Helper() {}
+func (f *fakeTB) Log(args ...interface{}) {
+	f.logs = append(f.logs, args[0].(string))
+}
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errs = append(f.errs, format)
+}
+
+func TestRecorder(t *testing.T) {
+	al, rec := alogtest.New(t)
+	now := time.Date(2021, 3, 8, 20, 33, 37, 0, time.UTC)
+	al.Clock = func() time.Time { return now }
+	tagDB := al.NewTag("DB")
+	tagNet := al.NewTag("Net")
+	errTimeout := errors.New("timeout")
+
+	al = al.With(func(e *alog.Entry) *alog.Entry { return e.Str("svc", "api") })
+	al.Trace().Writes("start")
+	al.Error(tagDB, tagNet).Int("status", 500).Err(errTimeout).
+		Dict("req", func(e *alog.Entry) { e.Str("method", "GET") }).
+		Ints("ids", []int{1, 2}).Writes("query failed")
+	al.Info(tagDB).Uint("status", 200).Writes("query done")
+
+	if rec.Len() != 3 {
+		t.Fatalf("unexpected records: %d", rec.Len())
+	}
+	r := rec.Expect(t, 1, alogtest.Level(alog.ErrorLevel), alogtest.Tag("DB"), alogtest.Field("status", 500))[0]
+	if !r.Time.Equal(now) || r.Message != "query failed" || strings.Join(r.Tags, ",") != "DB,Net" {
+		t.Errorf("unexpected record: %+v", r)
+	}
+	rec.Expect(t, 3, alogtest.Field("svc", "api"))
+	rec.Expect(t, 1, alogtest.Field("status", uint16(200)))
+	rec.Expect(t, 1, alogtest.Field("error", errTimeout), alogtest.Field("error", "timeout"))
+	rec.Expect(t, 1, alogtest.Field("req.method", "GET"), alogtest.Field("ids", []int{1, 2}))
+	rec.Expect(t, 2, alogtest.MinLevel(alog.InfoLevel))
+	rec.Expect(t, 0, alogtest.Tag("Net"), alogtest.Level(alog.InfoLevel))
+	rec.Expect(t, 1, alogtest.MessageContains("start"), alogtest.HasField("svc"))
+
+	// A failed expectation lists records.
+	ftb := &fakeTB{}
+	rec.Expect(ftb, 1, alogtest.Message("none"))
+	if len(ftb.errs) != 1 {
+		t.Errorf("unexpected errors: %v", ftb.errs)
+	}
+
+	rec.Reset()
+	if rec.Len() != 0 {
+		t.Errorf("unexpected records after reset: %d", rec.Len())
+	}
+}
+
+func TestRecorder_Concurrent(t *testing.T) {
+	al, rec := alogtest.New(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				al.Info().Int("g", i).Int("n", j).Writes("hello")
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, r := range rec.Records() {
+		if len(r.Fields) != 2 || r.Fields[0].Key != "g" || r.Fields[1].Key != "n" {
+			t.Fatalf("unexpected record: %s", r)
+		}
+	}
+	rec.Expect(t, 800, alogtest.Message("hello"))
+}
+
+func TestNewWriter(t *testing.T) {
+	ftb := &fakeTB{}
+	al := alog.New(alogtest.NewWriter(ftb))
+	al.Flag = alog.WithLevel
+	al.Info().Writes("hello")
+	if len(ftb.logs) != 1 || ftb.logs[0] != `{"level":"info","message":"hello"}` {
+		t.Errorf("unexpected logs: %q", ftb.logs)
+	}
+}
@@ -0,0 +1,191 @@
+package alogtest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gonyyi/alog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Matcher is a condition of a record for Find, Count and Expect.
+type Matcher struct {
+	desc string
+	fn   func(Record) bool
+}
+
+// String returns the description of the condition such as `status=500`.
+func (m Matcher) String() string {
+	return m.desc
+}
+
+// Match returns true if the record meets the condition.
+func (m Matcher) Match(r Record) bool {
+	return m.fn == nil || m.fn(r)
+}
+
+// Func returns a Matcher of a custom function with its description.
+func Func(desc string, fn func(Record) bool) Matcher {
+	return Matcher{desc: desc, fn: fn}
+}
+
+// Level matches records of the level.
+func Level(level alog.Level) Matcher {
+	return Func("level="+level.Name(), func(r Record) bool {
+		return r.Level == level
+	})
+}
+
+// MinLevel matches records of the level or higher.
+func MinLevel(level alog.Level) Matcher {
+	return Func("level>="+level.Name(), func(r Record) bool {
+		return r.Level >= level
+	})
+}
+
+// Tag matches records having all tag names.
+func Tag(names ...string) Matcher {
+	return Func("tag="+strings.Join(names, ","), func(r Record) bool {
+		for i := 0; i < len(names); i++ {
+			if !r.HasTag(names[i]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Message matches records of the message.
+func Message(msg string) Matcher {
+	return Func(fmt.Sprintf("message=%q", msg), func(r Record) bool {
+		return r.Message == msg
+	})
+}
+
+// MessageContains matches records of a message containing s.
+func MessageContains(s string) Matcher {
+	return Func(fmt.Sprintf("message~%q", s), func(r Record) bool {
+		return strings.Contains(r.Message, s)
+	})
+}
+
+// HasField matches records having the key. See Record.Field.
+func HasField(key string) Matcher {
+	return Func("has "+key, func(r Record) bool {
+		_, ok := r.Field(key)
+		return ok
+	})
+}
+
+// Field matches records having the key with the value. Integers of any
+// type are compared by value, so Field("status", 500) matches both
+// Int and Uint. An error matches with errors.Is or the same message,
+// and a string matches an error of the message.
+func Field(key string, val interface{}) Matcher {
+	return Func(fmt.Sprintf("%s=%v", key, val), func(r Record) bool {
+		kv, ok := r.Field(key)
+		return ok && equal(Value(kv), val)
+	})
+}
+
+// equal compares an actual value of a field with an expected value.
+func equal(act, exp interface{}) bool {
+	if aerr, ok := act.(error); ok || act == nil {
+		switch e := exp.(type) {
+		case nil:
+			return act == nil
+		case error:
+			return aerr != nil && (errors.Is(aerr, e) || aerr.Error() == e.Error())
+		case string:
+			return aerr != nil && aerr.Error() == e
+		}
+	}
+	if at, ok := act.(time.Time); ok {
+		et, ok := exp.(time.Time)
+		return ok && at.Equal(et)
+	}
+	return reflect.DeepEqual(normalize(act), normalize(exp))
+}
+
+// normalize converts integers to int64, or uint64 if too large,
+// float32 to float64, and slices of them, so they can be compared.
+func normalize(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, ok := v.(time.Duration); ok {
+			return v
+		}
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u > 1<<63-1 {
+			return u
+		}
+		return int64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v // []byte
+		}
+		out := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out[i] = normalize(rv.Index(i).Interface())
+		}
+		return out
+	}
+	return v
+}
+
+// Find returns records matching all conditions.
+func (r *Recorder) Find(ms ...Matcher) []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Record
+	for i := 0; i < len(r.records); i++ {
+		if matchAll(r.records[i], ms) {
+			out = append(out, r.records[i])
+		}
+	}
+	return out
+}
+
+// Count returns the number of records matching all conditions.
+func (r *Recorder) Count(ms ...Matcher) int {
+	return len(r.Find(ms...))
+}
+
+// Expect reports an error to tb unless exactly n records match all
+// conditions, and returns records matched. Records will be listed in
+// the error message.
+//   eg. rec.Expect(t, 1, alogtest.Level(alog.ErrorLevel), alogtest.Tag("DB"), alogtest.Field("status", 500))
+//       rec.Expect(t, 0, alogtest.MinLevel(alog.WarnLevel))
+func (r *Recorder) Expect(tb testing.TB, n int, ms ...Matcher) []Record {
+	tb.Helper()
+	found := r.Find(ms...)
+	if len(found) != n {
+		desc := make([]string, len(ms))
+		for i := 0; i < len(ms); i++ {
+			desc[i] = ms[i].String()
+		}
+		var sb strings.Builder
+		recs := r.Records()
+		for i := 0; i < len(recs); i++ {
+			sb.WriteString("\n\t" + recs[i].String())
+		}
+		tb.Errorf("alogtest: expected %d entries of {%s}, found %d; recorded:%s", n, strings.Join(desc, ", "), len(found), sb.String())
+	}
+	return found
+}
+
+// matchAll returns true if the record matches all ms.
+func matchAll(r Record, ms []Matcher) bool {
+	for i := 0; i < len(ms); i++ {
+		if !ms[i].Match(r) {
+			return false
+		}
+	}
+	return true
+}
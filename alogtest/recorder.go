@@ -0,0 +1,286 @@
+// Package alogtest provides a logger recording entries in memory
+// with query and assertion helpers for tests.
+//   eg. al, rec := alogtest.New(t)
+//       tagDB := al.NewTag("DB")
+//       al.Error(tagDB).Int("status", 500).Writes("query failed")
+//       rec.Expect(t, 1, alogtest.Level(alog.ErrorLevel), alogtest.Tag("DB"), alogtest.Field("status", 500))
+package alogtest
+
+import (
+	"fmt"
+	"github.com/gonyyi/alog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// New returns a logger recording all entries, including trace level,
+// to the recorder. If tb is not nil, entries will also be logged
+// by tb.Log in JSON, so they will be shown when a test fails.
+func New(tb testing.TB) (alog.Logger, *Recorder) {
+	rec := NewRecorder()
+	al := alog.New(nil)
+	al.Control.Level = alog.TraceLevel
+	al = al.SetFormatter(rec)
+	if tb != nil {
+		s := alog.NewSink(NewWriter(tb), nil)
+		s.Level = alog.TraceLevel
+		al = al.AddSink(s)
+	}
+	return al, rec
+}
+
+// Record is an entry recorded.
+type Record struct {
+	Time    time.Time
+	Level   alog.Level
+	Tag     alog.Tag
+	Tags    []string // Tags are names of Tag
+	Message string
+	Fields  []alog.KeyValue // Fields include bound fields, and caller and func if flagged
+}
+
+// HasTag returns true if the record has the tag name.
+func (r Record) HasTag(name string) bool {
+	for i := 0; i < len(r.Tags); i++ {
+		if r.Tags[i] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the first field of the key. Fields in a dict can be found
+// with dot notation such as "http.method".
+func (r Record) Field(key string) (alog.KeyValue, bool) {
+	return findKV(r.Fields, key)
+}
+
+// Value returns the value of the field as a Go type; int64 for KvInt,
+// uint64 for KvUint, []string for KvStrs, map[string]interface{} for KvDict, etc.
+// If not found, nil will be returned.
+func (r Record) Value(key string) interface{} {
+	if kv, ok := r.Field(key); ok {
+		return Value(kv)
+	}
+	return nil
+}
+
+// String returns the record in a line such as `error [DB] "query failed" status=500`.
+func (r Record) String() string {
+	var sb strings.Builder
+	sb.WriteString(r.Level.Name())
+	if len(r.Tags) > 0 {
+		sb.WriteString(" [" + strings.Join(r.Tags, ",") + "]")
+	}
+	fmt.Fprintf(&sb, " %q", r.Message)
+	for i := 0; i < len(r.Fields); i++ {
+		fmt.Fprintf(&sb, " %s=%v", r.Fields[i].Key, Value(r.Fields[i]))
+	}
+	return sb.String()
+}
+
+// findKV finds a KeyValue of the key in kvs. If not found, it will
+// look for a dict matching the beginning of the key.
+func findKV(kvs []alog.KeyValue, key string) (alog.KeyValue, bool) {
+	for i := 0; i < len(kvs); i++ {
+		if kvs[i].Key == key {
+			return kvs[i], true
+		}
+	}
+	for i := 0; i < len(kvs); i++ {
+		if kvs[i].Vtype == alog.KvDict && strings.HasPrefix(key, kvs[i].Key+".") {
+			if kv, ok := findKV(kvs[i].Vkvs, key[len(kvs[i].Key)+1:]); ok {
+				return kv, true
+			}
+		}
+	}
+	return alog.KeyValue{}, false
+}
+
+// Value returns the value of a KeyValue as a Go type. See Record.Value.
+func Value(kv alog.KeyValue) interface{} {
+	switch kv.Vtype {
+	case alog.KvInt:
+		return kv.Vint
+	case alog.KvUint:
+		return kv.Uint()
+	case alog.KvFloat64:
+		return kv.Vf64
+	case alog.KvString:
+		return kv.Vstr
+	case alog.KvBool:
+		return kv.Vbool
+	case alog.KvError:
+		return kv.Verr
+	case alog.KvDuration:
+		return kv.Duration()
+	case alog.KvTime:
+		return kv.Time()
+	case alog.KvBytes, alog.KvHex, alog.KvBase64:
+		return kv.Vbyte
+	case alog.KvStrs:
+		out := make([]string, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			out[i] = kv.Vkvs[i].Vstr
+		}
+		return out
+	case alog.KvInts:
+		out := make([]int64, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			out[i] = kv.Vkvs[i].Vint
+		}
+		return out
+	case alog.KvFloats:
+		out := make([]float64, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			out[i] = kv.Vkvs[i].Vf64
+		}
+		return out
+	case alog.KvBools:
+		out := make([]bool, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			out[i] = kv.Vkvs[i].Vbool
+		}
+		return out
+	case alog.KvErrs:
+		out := make([]error, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			out[i] = kv.Vkvs[i].Verr
+		}
+		return out
+	case alog.KvStack:
+		// frames as "func file:line"
+		out := make([]string, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			out[i] = fmt.Sprintf("%s %s:%d", kv.Vkvs[i].Key, kv.Vkvs[i].Vstr, kv.Vkvs[i].Vint)
+		}
+		return out
+	case alog.KvDict:
+		out := make(map[string]interface{}, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			out[kv.Vkvs[i].Key] = Value(kv.Vkvs[i])
+		}
+		return out
+	case alog.KvAny:
+		return kv.Vany
+	default:
+		return nil
+	}
+}
+
+// NewRecorder returns a recorder. It's a Formatter recording entries
+// instead of formatting them; use it with Logger.SetFormatter,
+// or use New for a logger with it.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Recorder is a Formatter keeping entries as records.
+// As the formatter is called by each step of an entry, it holds the lock
+// from Begin until Write, so that entries written concurrently won't mix.
+type Recorder struct {
+	mu        sync.Mutex
+	records   []Record
+	cur       Record
+	tagBucket *alog.TagBucket
+}
+
+// Records returns a copy of records.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Record, len(r.records))
+	copy(out, r.records)
+	return out
+}
+
+// Len returns the number of records.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.records)
+}
+
+// Reset removes all records.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.records = nil
+	r.mu.Unlock()
+}
+
+func (r *Recorder) Init(w alog.Writer, formatFlag alog.Flag, tagBucket *alog.TagBucket) {
+	r.tagBucket = tagBucket
+}
+
+func (r *Recorder) Begin(dst []byte) []byte {
+	r.mu.Lock()
+	r.cur = Record{}
+	return dst
+}
+
+func (r *Recorder) AddTime(dst []byte, t time.Time) []byte {
+	r.cur.Time = t
+	return dst
+}
+
+func (r *Recorder) AddLevel(dst []byte, level alog.Level) []byte {
+	r.cur.Level = level
+	return dst
+}
+
+func (r *Recorder) AddTag(dst []byte, tag alog.Tag) []byte {
+	r.cur.Tag = tag
+	if r.tagBucket != nil {
+		for i := 0; i < 64; i++ {
+			if t := alog.Tag(1) << i; tag&t != 0 {
+				r.cur.Tags = append(r.cur.Tags, r.tagBucket.Name(t))
+			}
+		}
+	}
+	return dst
+}
+
+func (r *Recorder) AddMsg(dst []byte, s string) []byte {
+	r.cur.Message = s
+	return dst
+}
+
+// AddKVs copies kvs, as they will be reused by the logger.
+func (r *Recorder) AddKVs(dst []byte, kvs []alog.KeyValue) []byte {
+	r.cur.Fields = copyKVs(kvs)
+	return dst
+}
+
+func (r *Recorder) End(dst []byte) []byte {
+	return dst
+}
+
+// Write adds the record, and releases the lock held by Begin.
+func (r *Recorder) Write(dst []byte, level alog.Level, tag alog.Tag) (int, error) {
+	r.records = append(r.records, r.cur)
+	r.cur = Record{}
+	r.mu.Unlock()
+	return len(dst), nil
+}
+
+func (r *Recorder) Close() error {
+	return nil
+}
+
+// copyKVs returns a deep copy of kvs.
+func copyKVs(kvs []alog.KeyValue) []alog.KeyValue {
+	if len(kvs) == 0 {
+		return nil
+	}
+	out := make([]alog.KeyValue, len(kvs))
+	copy(out, kvs)
+	for i := 0; i < len(out); i++ {
+		out[i].Vkvs = copyKVs(out[i].Vkvs)
+		if out[i].Vbyte != nil {
+			out[i].Vbyte = append([]byte{}, out[i].Vbyte...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+package alogtest
+
+import (
+	"bytes"
+	"github.com/gonyyi/alog"
+	"testing"
+)
+
+// NewWriter returns a writer forwarding each line to tb.Log, so logs
+// are shown with the test when it fails or with `go test -v`. As tb.Log
+// can't be used after the test is done, the logger shouldn't be used
+// by goroutines outliving the test.
+//   eg. al := alog.New(alogtest.NewWriter(t))
+func NewWriter(tb testing.TB) *tWriter {
+	return &tWriter{tb: tb}
+}
+
+type tWriter struct {
+	tb testing.TB
+}
+
+// Write logs each line of p by tb.Log.
+func (w *tWriter) Write(p []byte) (int, error) {
+	w.tb.Helper()
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte{'\n'}) {
+		w.tb.Log(string(line))
+	}
+	return len(p), nil
+}
+
+func (w *tWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
+	w.tb.Helper()
+	return w.Write(p)
+}
@@ -0,0 +1,192 @@
+package alog
+
+import (
+	"encoding/base64"
+	"time"
+)
+
+// AppendHex appends hex encoded b to dst.
+func AppendHex(dst []byte, b []byte) []byte {
+	for i := 0; i < len(b); i++ {
+		dst = append(dst, hex[b[i]>>4], hex[b[i]&0xF])
+	}
+	return dst
+}
+
+// AppendBase64 appends standard base64 encoded b to dst.
+func AppendBase64(dst []byte, b []byte) []byte {
+	n := len(dst)
+	dst = append(dst, make([]byte, base64.StdEncoding.EncodedLen(len(b)))...)
+	base64.StdEncoding.Encode(dst[n:], b)
+	return dst
+}
+
+// AppendJSONString appends s to dst as an escaped JSON string.
+// If quote is false, double quotes around s won't be added.
+func AppendJSONString(dst []byte, s string, quote bool) []byte {
+	return appendString(dst, s, quote)
+}
+
+// AppendJSONBytes appends b to dst as an escaped JSON string.
+// If quote is false, double quotes around b won't be added.
+func AppendJSONBytes(dst []byte, b []byte, quote bool) []byte {
+	return appendBytes(dst, b, quote)
+}
+
+// AppendRFC3339 appends t to dst in RFC 3339 format such as
+// "2021-03-08T20:33:37+09:00" without allocation. If nano is true,
+// it will always have 9 digits of nanoseconds so it can be sorted,
+// such as "2021-03-08T20:33:37.123000000Z". t is used in its location,
+// so use t.In or t.UTC for other time zones.
+func AppendRFC3339(dst []byte, t time.Time, nano bool) []byte {
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	dst = appendDigits(dst, y, 4)
+	dst = appendDigits(append(dst, '-'), int(mo), 2)
+	dst = appendDigits(append(dst, '-'), d, 2)
+	dst = appendDigits(append(dst, 'T'), h, 2)
+	dst = appendDigits(append(dst, ':'), mi, 2)
+	dst = appendDigits(append(dst, ':'), s, 2)
+	if nano {
+		dst = appendDigits(append(dst, '.'), t.Nanosecond(), 9)
+	}
+	_, off := t.Zone()
+	if off == 0 {
+		return append(dst, 'Z')
+	}
+	if off < 0 {
+		dst = append(dst, '-')
+		off = -off
+	} else {
+		dst = append(dst, '+')
+	}
+	dst = appendDigits(dst, off/3600, 2)
+	return appendDigits(append(dst, ':'), off%3600/60, 2)
+}
+
+// appendDigits appends n with leading zeros to have width digits.
+// Negative n will have '-' before the digits, such as "-0001" for
+// the year -1, same as time.Format.
+func appendDigits(dst []byte, n int, width int) []byte {
+	if n < 0 {
+		dst = append(dst, '-')
+		n = -n
+	}
+	var buf [20]byte
+	i := len(buf)
+	for n >= 10 || width > 1 {
+		i--
+		buf[i] = byte('0' + n%10)
+		n /= 10
+		width--
+	}
+	i--
+	buf[i] = byte('0' + n)
+	return append(dst, buf[i:]...)
+}
+
+// AppendDuration appends d to dst in the same format as
+// time.Duration.String() such as "1h2m0.5s", but without allocation.
+func AppendDuration(dst []byte, d time.Duration) []byte {
+	// This is from time.Duration.String()
+	var buf [32]byte
+	w := len(buf)
+
+	u := uint64(d)
+	neg := d < 0
+	if neg {
+		u = -u
+	}
+
+	if u < uint64(time.Second) {
+		// Special case: if duration is smaller than a second,
+		// use smaller units, like 1.2ms
+		var prec int
+		w--
+		buf[w] = 's'
+		w--
+		switch {
+		case u == 0:
+			return append(dst, '0', 's')
+		case u < uint64(time.Microsecond):
+			prec = 0
+			buf[w] = 'n'
+		case u < uint64(time.Millisecond):
+			prec = 3
+			// U+00B5 'µ' micro sign == 0xC2 0xB5
+			w-- // Need room for two bytes.
+			copy(buf[w:], "µ")
+		default:
+			prec = 6
+			buf[w] = 'm'
+		}
+		w, u = durFrac(buf[:w], u, prec)
+		w = durInt(buf[:w], u)
+	} else {
+		w--
+		buf[w] = 's'
+		w, u = durFrac(buf[:w], u, 9)
+		// u is now integer seconds
+		w = durInt(buf[:w], u%60)
+		u /= 60
+		// u is now integer minutes
+		if u > 0 {
+			w--
+			buf[w] = 'm'
+			w = durInt(buf[:w], u%60)
+			u /= 60
+			// u is now integer hours
+			if u > 0 {
+				w--
+				buf[w] = 'h'
+				w = durInt(buf[:w], u)
+			}
+		}
+	}
+
+	if neg {
+		w--
+		buf[w] = '-'
+	}
+	return append(dst, buf[w:]...)
+}
+
+// durFrac formats the fraction of v/10**prec (e.g., ".12345") into the
+// tail of buf, omitting trailing zeros. It omits the decimal
+// point too when the fraction is 0. It returns the index where the
+// output bytes begin and the value v/10**prec.
+func durFrac(buf []byte, v uint64, prec int) (nw int, nv uint64) {
+	w := len(buf)
+	print := false
+	for i := 0; i < prec; i++ {
+		digit := v % 10
+		print = print || digit != 0
+		if print {
+			w--
+			buf[w] = byte(digit) + '0'
+		}
+		v /= 10
+	}
+	if print {
+		w--
+		buf[w] = '.'
+	}
+	return w, v
+}
+
+// durInt formats v into the tail of buf.
+// It returns the index where the output begins.
+func durInt(buf []byte, v uint64) int {
+	w := len(buf)
+	if v == 0 {
+		w--
+		buf[w] = '0'
+	} else {
+		for v > 0 {
+			w--
+			buf[w] = byte(v%10) + '0'
+			v /= 10
+		}
+	}
+	return w
+}
@@ -0,0 +1,62 @@
+package alog_test
+
+import (
+	"github.com/gonyyi/alog"
+	"testing"
+	"time"
+)
+
+func TestAppendDuration(t *testing.T) {
+	for _, d := range []time.Duration{
+		0, 1, 999, time.Microsecond, 1500 * time.Microsecond, time.Second,
+		-2 * time.Second, 90 * time.Minute, 26*time.Hour + 3*time.Second + 5,
+	} {
+		if act := string(alog.AppendDuration(nil, d)); act != d.String() {
+			t.Errorf("AppendDuration(%d) // exp=<%s>, act=<%s>", d, d.String(), act)
+		}
+	}
+}
+
+func TestAppendHex(t *testing.T) {
+	if act := string(alog.AppendHex([]byte("x="), []byte{0, 0x1f, 0xab})); act != "x=001fab" {
+		t.Errorf("AppendHex() // act=<%s>", act)
+	}
+	if act := string(alog.AppendBase64([]byte("x="), []byte("gon"))); act != "x=Z29u" {
+		t.Errorf("AppendBase64() // act=<%s>", act)
+	}
+}
+
+func TestAppendJSONString(t *testing.T) {
+	if act := string(alog.AppendJSONString(nil, "a\"b\n", true)); act != `"a\"b\n"` {
+		t.Errorf("AppendJSONString() // act=<%s>", act)
+	}
+	if act := string(alog.AppendJSONBytes(nil, []byte("a\"b\n"), false)); act != `a\"b\n` {
+		t.Errorf("AppendJSONBytes() // act=<%s>", act)
+	}
+}
+
+func TestAppendRFC3339(t *testing.T) {
+	est := time.FixedZone("EST", -5*3600)
+	ist := time.FixedZone("IST", 5*3600+30*60)
+	for _, tm := range []time.Time{
+		time.Date(2021, 3, 8, 20, 33, 37, 123456789, time.UTC),
+		time.Date(999, 12, 31, 0, 0, 0, 0, est),
+		time.Date(2021, 1, 2, 3, 4, 5, 6000, ist),
+		time.Date(-1, 1, 2, 3, 4, 5, 0, time.UTC),
+		time.Date(-12345, 1, 2, 3, 4, 5, 0, time.UTC),
+		time.Date(10000, 1, 2, 3, 4, 5, 0, time.UTC),
+	} {
+		if act, exp := string(alog.AppendRFC3339(nil, tm, false)), tm.Format(time.RFC3339); act != exp {
+			t.Errorf("AppendRFC3339() // exp=<%s>, act=<%s>", exp, act)
+		}
+		if act, exp := string(alog.AppendRFC3339(nil, tm, true)), tm.Format("2006-01-02T15:04:05.000000000Z07:00"); act != exp {
+			t.Errorf("AppendRFC3339(nano) // exp=<%s>, act=<%s>", exp, act)
+		}
+	}
+	tm := time.Now()
+	if n := testing.AllocsPerRun(100, func() {
+		alog.AppendRFC3339(make([]byte, 0, 64)[:0], tm, true)
+	}); n != 0 {
+		t.Errorf("AppendRFC3339() // allocs=%v", n)
+	}
+}
@@ -0,0 +1,54 @@
+package alog
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// callerDepth is the depth from runtime.Caller to the user's code
+// when called from Entry.write: write <- Writes/Write <- user.
+const callerDepth = 3
+
+// caller returns pc, file and line of the user's code that wrote the log.
+// skip is an additional number of frames to skip for wrappers.
+func caller(skip int) (pc uintptr, file string, line int) {
+	pc, file, line, ok := runtime.Caller(callerDepth + skip)
+	if !ok {
+		return 0, "", 0
+	}
+	return pc, callerFile(file), line
+}
+
+// callerFile trims the full path of the file to `dir/file.go`.
+func callerFile(file string) string {
+	n := 0
+	for i := len(file) - 1; i >= 0; i-- {
+		if file[i] == '/' {
+			n++
+			if n == 2 {
+				return file[i+1:]
+			}
+		}
+	}
+	return file
+}
+
+// callerFunc returns the function name of the pc as `pkg.Func` format.
+func callerFunc(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '/' {
+			return name[i+1:]
+		}
+	}
+	return name
+}
+
+// appendCaller appends `file:line` to dst.
+func appendCaller(dst []byte, file string, line int) []byte {
+	return strconv.AppendInt(append(appendString(dst, file, false), ':'), int64(line), 10)
+}
@@ -0,0 +1,51 @@
+package alog_test
+
+import (
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+// thisLine returns `dir/file.go:line` of the caller.
+func thisLine() string {
+	_, file, line, _ := runtime.Caller(1)
+	return filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file) + ":" + strconv.Itoa(line+1)
+}
+
+// wrapInfo is a wrapper that writes a log on behalf of the caller.
+func wrapInfo(l *alog.Logger, msg string) {
+	l.Info(0).Writes(msg)
+}
+
+func TestEntry_Caller(t *testing.T) {
+	reset()
+	log.Flag = alog.WithLevel | alog.WithCaller
+	exp := thisLine()
+	log.Info(0).Writes("done")
+	check(t, `{"level":"info","caller":"`+exp+`","message":"done"}`)
+
+	log.Flag = alog.WithLevel | alog.WithCaller | alog.WithFunc
+	exp = thisLine()
+	log.Info(0).Int("a", 1).Writes("done")
+	check(t, `{"level":"info","caller":"`+exp+`","func":"alog_test.TestEntry_Caller","message":"done","a":1}`)
+
+	// CallerSkip for wrappers
+	log.Flag = alog.WithCaller
+	log.CallerSkip = 1
+	exp = thisLine()
+	wrapInfo(&log, "done")
+	log.CallerSkip = 0
+	check(t, `{"caller":"`+exp+`","message":"done"}`)
+
+	// Custom formatter receives caller as leading KeyValues.
+	log.Flag = alog.WithLevel | alog.WithCaller | alog.WithFunc
+	log = log.Ext(ext.LogFmt.Text())
+	exp = thisLine()
+	log.Info(0).Int("a", 1).Writes("done")
+	check(t, `INF [] done // caller="`+exp+`", func="alog_test.TestEntry_Caller", a=1`)
+
+	reset()
+}
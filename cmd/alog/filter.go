@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/reader"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filter decides which records will be shown.
+type filter struct {
+	level        alog.Level  // level is the minimum level; 0 for all
+	tags         [][]tagTerm // tags is OR of terms joined by AND; nil for all
+	since, until time.Time   // since is inclusive, and until is exclusive
+	preds        []predicate // all predicates must match
+}
+
+// tagTerm is a tag name of a tag expression, negated by `!`.
+type tagTerm struct {
+	name string
+	not  bool
+}
+
+// parseTags parses a tag expression; `,` for OR, `+` for AND, and `!` for NOT.
+// eg. "DB+!Cache,Net" matches records with DB but not Cache, or with Net.
+func parseTags(s string) ([][]tagTerm, error) {
+	var out [][]tagTerm
+	for _, or := range strings.Split(s, ",") {
+		var all []tagTerm
+		for _, name := range strings.Split(or, "+") {
+			t := tagTerm{name: strings.TrimSpace(name)}
+			if strings.HasPrefix(t.name, "!") {
+				t.name, t.not = strings.TrimSpace(t.name[1:]), true
+			}
+			if t.name == "" {
+				return nil, fmt.Errorf("invalid tag expression %q", s)
+			}
+			all = append(all, t)
+		}
+		out = append(out, all)
+	}
+	return out, nil
+}
+
+// parseTime parses a time such as "2021-03-08T20:33:37+09:00", "2021-03-08 20:33:37",
+// "2021-03-08", or a duration before now such as "15m".
+func parseTime(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// predicate is a condition of a field such as `status>=500`.
+// Without an operator, it checks the field exists.
+type predicate struct {
+	key, op, val string
+}
+
+// predicateOps are operators; two characters ones first.
+var predicateOps = []string{">=", "<=", "!=", "=", ">", "<", "~"}
+
+// parsePredicate parses a field predicate such as "status>=500",
+// "method=GET", "error~timeout" or "user". A key in a dict can be
+// given with dot notation such as "req.method=GET".
+func parsePredicate(s string) (predicate, error) {
+	i := strings.IndexAny(s, "=!<>~")
+	if i < 0 {
+		if s = strings.TrimSpace(s); s == "" {
+			return predicate{}, errors.New("empty field predicate")
+		}
+		return predicate{key: s}, nil
+	}
+	p := predicate{key: strings.TrimSpace(s[:i])}
+	for _, op := range predicateOps {
+		if strings.HasPrefix(s[i:], op) {
+			p.op, p.val = op, s[i+len(op):]
+			break
+		}
+	}
+	if p.key == "" || p.op == "" {
+		return predicate{}, fmt.Errorf("invalid field predicate %q", s)
+	}
+	return p, nil
+}
+
+// match returns true if the record meets all conditions.
+func (f *filter) match(r *reader.Record) bool {
+	if f.level > 0 && r.Level < f.level {
+		return false
+	}
+	if !f.since.IsZero() && (r.Time.IsZero() || r.Time.Before(f.since)) {
+		return false
+	}
+	if !f.until.IsZero() && (r.Time.IsZero() || !r.Time.Before(f.until)) {
+		return false
+	}
+	if f.tags != nil && !f.matchTags(r) {
+		return false
+	}
+	for i := 0; i < len(f.preds); i++ {
+		if !f.preds[i].match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTags returns true if any of the terms joined by AND matches.
+func (f *filter) matchTags(r *reader.Record) bool {
+	for _, all := range f.tags {
+		ok := true
+		for _, t := range all {
+			if r.HasTag(t.name) == t.not {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns true if the field meets the predicate. Numbers and
+// durations such as "1.5s" are compared by value, and others as strings.
+// A record without the field never matches.
+func (p *predicate) match(r *reader.Record) bool {
+	kv, ok := r.Field(p.key)
+	if !ok {
+		return false
+	}
+	if p.op == "" {
+		return true
+	}
+	s := valueString(kv)
+	if p.op == "~" {
+		return strings.Contains(s, p.val)
+	}
+
+	c, ok := compareNum(kv, p.val)
+	if !ok {
+		c, ok = compareDuration(s, p.val)
+	}
+	if !ok {
+		c = strings.Compare(s, p.val)
+	}
+	switch p.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	default: // "<="
+		return c <= 0
+	}
+}
+
+// compareNum compares a number field with val.
+func compareNum(kv alog.KeyValue, val string) (int, bool) {
+	switch kv.Vtype {
+	case alog.KvInt:
+		if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return compareInt(kv.Vint, i), true
+		}
+		return compareFloat(float64(kv.Vint), val)
+	case alog.KvUint:
+		if u, err := strconv.ParseUint(val, 10, 64); err == nil {
+			switch {
+			case kv.Uint() < u:
+				return -1, true
+			case kv.Uint() > u:
+				return 1, true
+			}
+			return 0, true
+		}
+		return compareFloat(float64(kv.Uint()), val)
+	case alog.KvFloat64:
+		return compareFloat(kv.Vf64, val)
+	}
+	return 0, false
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a float64, val string) (int, bool) {
+	b, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	}
+	return 0, true
+}
+
+// compareDuration compares s and val if both are durations.
+func compareDuration(s, val string) (int, bool) {
+	a, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, false
+	}
+	b, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, false
+	}
+	return compareInt(int64(a), int64(b)), true
+}
+
+// valueString returns a field value as a string; array
+// items are joined by a comma.
+func valueString(kv alog.KeyValue) string {
+	switch kv.Vtype {
+	case alog.KvString:
+		return kv.Vstr
+	case alog.KvInt:
+		return strconv.FormatInt(kv.Vint, 10)
+	case alog.KvUint:
+		return strconv.FormatUint(kv.Uint(), 10)
+	case alog.KvFloat64:
+		return strconv.FormatFloat(kv.Vf64, 'f', -1, 64)
+	case alog.KvBool:
+		return strconv.FormatBool(kv.Vbool)
+	case alog.KvStrs, alog.KvInts, alog.KvFloats, alog.KvBools:
+		items := make([]string, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			items[i] = valueString(kv.Vkvs[i])
+		}
+		return strings.Join(items, ",")
+	case alog.KvDict:
+		items := make([]string, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			items[i] = kv.Vkvs[i].Key + "=" + valueString(kv.Vkvs[i])
+		}
+		return "{" + strings.Join(items, ",") + "}"
+	case alog.KvAny:
+		if kv.Vany == nil {
+			return "null"
+		}
+		return fmt.Sprint(kv.Vany)
+	default:
+		return fmt.Sprint(kv.Vkvs)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/reader"
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2021, 3, 8, 20, 33, 37, 0, time.UTC)
+	rec := reader.Record{
+		Time:  now,
+		Level: alog.ErrorLevel,
+		Tags:  []string{"DB", "Cache"},
+		Fields: []alog.KeyValue{
+			{Key: "status", Vtype: alog.KvInt, Vint: 500},
+			{Key: "ratio", Vtype: alog.KvFloat64, Vf64: 0.5},
+			{Key: "method", Vtype: alog.KvString, Vstr: "GET"},
+			{Key: "took", Vtype: alog.KvString, Vstr: "1.5s"},
+			{Key: "error", Vtype: alog.KvString, Vstr: "read: timeout"},
+			{Key: "req", Vtype: alog.KvDict, Vkvs: []alog.KeyValue{{Key: "path", Vtype: alog.KvString, Vstr: "/a"}}},
+		},
+	}
+
+	for _, tc := range []struct {
+		tags  string
+		preds []string
+		level alog.Level
+		since string
+		until string
+		exp   bool
+	}{
+		{exp: true},
+		{level: alog.WarnLevel, exp: true},
+		{level: alog.FatalLevel, exp: false},
+		{tags: "DB", exp: true},
+		{tags: "Net,DB+Cache", exp: true},
+		{tags: "DB+!Cache", exp: false},
+		{tags: "!Net", exp: true},
+		{preds: []string{"status>=500", "status<501", "method=GET"}, exp: true},
+		{preds: []string{"status!=500"}, exp: false},
+		{preds: []string{"ratio>0.25", "took>1s", "took<=1500ms"}, exp: true},
+		{preds: []string{"error~timeout", "req.path=/a", "req"}, exp: true},
+		{preds: []string{"user"}, exp: false},
+		{since: "5m", exp: true},
+		{since: "2021-03-08 20:40:00", exp: false},
+		{since: "2021-03-08", until: "2021-03-09", exp: true},
+		{until: "2021-03-08T20:33:37Z", exp: false},
+	} {
+		f := filter{level: tc.level}
+		var err error
+		if tc.tags != "" {
+			if f.tags, err = parseTags(tc.tags); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, s := range tc.preds {
+			p, err := parsePredicate(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.preds = append(f.preds, p)
+		}
+		if tc.since != "" {
+			f.since, _ = parseTime(tc.since, now, time.UTC)
+		}
+		if tc.until != "" {
+			f.until, _ = parseTime(tc.until, now, time.UTC)
+		}
+		if act := f.match(&rec); act != tc.exp {
+			t.Errorf("unexpected match for %+v: %v", tc, act)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, s := range []string{"", "=500", "status!"} {
+		if _, err := parsePredicate(s); err == nil {
+			t.Errorf("expected an error for predicate %q", s)
+		}
+	}
+	for _, s := range []string{"DB,", "DB+!"} {
+		if _, err := parseTags(s); err == nil {
+			t.Errorf("expected an error for tags %q", s)
+		}
+	}
+	if _, err := parseTime("yesterday", time.Now(), time.UTC); err == nil {
+		t.Errorf("expected an error for time")
+	}
+}
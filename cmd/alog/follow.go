@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// followReader reads a file, and at the end, waits for more to be
+// written until done is closed. If the file was truncated, such as
+// by a log rotation with copytruncate, it reads from the beginning.
+// If the file was renamed by a log rotation and a new file is created
+// with the name, it reads the new file from the beginning.
+type followReader struct {
+	name string
+	f    *os.File
+	done <-chan struct{}
+	poll time.Duration
+	off  int64
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.f.Read(p)
+		r.off += int64(n)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		info, err := r.f.Stat()
+		if err == nil && info.Size() < r.off {
+			if _, err := r.f.Seek(0, io.SeekStart); err != nil {
+				return 0, err
+			}
+			r.off = 0
+			continue
+		}
+		// Until the new file is created, keep waiting on the old one.
+		if cur, serr := os.Stat(r.name); err == nil && serr == nil && !os.SameFile(info, cur) {
+			f, err := os.Open(r.name)
+			if err != nil {
+				return 0, err
+			}
+			r.f.Close()
+			r.f, r.off = f, 0
+			continue
+		}
+		select {
+		case <-r.done:
+			return 0, io.EOF
+		case <-time.After(r.poll):
+		}
+	}
+}
+
+// Close closes the file being read.
+func (r *followReader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,271 @@
+// Command alog reads alog JSON logs from files or stdin, filters them,
+// and prints them in a format such as a colored text.
+//   eg. alog -level warn -tag 'DB+!Cache,Net' -where 'status>=500' app.log
+//       alog -f -format logfmt -since 15m app.log
+//       tail -n 1000 app.log | alog -q -summary
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/reader"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	done := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		close(done)
+	}()
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, done))
+}
+
+// predicates is a flag of field predicates which can be repeated.
+type predicates []predicate
+
+func (p *predicates) String() string {
+	return fmt.Sprint(*p)
+}
+
+func (p *predicates) Set(s string) error {
+	pred, err := parsePredicate(s)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, pred)
+	return nil
+}
+
+// run runs the command, and returns the exit code. Following
+// files stops when done is closed.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, done <-chan struct{}) int {
+	fs := flag.NewFlagSet("alog", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: alog [flags] [file ...]\n\nReads alog JSON logs from files, or stdin if none or \"-\".\n\nflags:")
+		fs.PrintDefaults()
+	}
+	var (
+		format  = fs.String("format", "color", "output format: color, text, logfmt, otel or json")
+		level   = fs.String("level", "", "minimum level such as warn")
+		tags    = fs.String("tag", "", "tag expression; ',' for OR, '+' for AND and '!' for NOT such as 'DB+!Cache,Net'")
+		since   = fs.String("since", "", "show entries at or after a time such as 2021-03-08T20:00:00Z, '2021-03-08 20:00:00' or 15m (ago)")
+		until   = fs.String("until", "", "show entries before a time; same as -since")
+		follow  = fs.Bool("f", false, "follow files for new entries until interrupted")
+		sum     = fs.Bool("summary", false, "print counts per level and tag at the end")
+		quiet   = fs.Bool("q", false, "don't print entries; with -summary")
+		utc     = fs.Bool("utc", false, "date and time of entries are in UTC (written with WithUTC), and times are shown in UTC")
+		timeMs  = fs.Bool("ms", false, "time of entries has milliseconds (written with WithTimeMs)")
+		preds   predicates
+		flt     filter
+		loc     = time.Local
+		summary = newSummary()
+	)
+	fs.Var(&preds, "where", "field predicate such as 'status>=500', 'method=GET', 'error~timeout' or 'user'; can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// Filters
+	if *utc {
+		loc = time.UTC
+	}
+	fail := func(err error) int {
+		fmt.Fprintln(stderr, "alog:", err)
+		return 2
+	}
+	if *level != "" {
+		l, ok := alog.ParseLevel(*level)
+		if !ok {
+			return fail(fmt.Errorf("unknown level %q", *level))
+		}
+		flt.level = l
+	}
+	if *tags != "" {
+		t, err := parseTags(*tags)
+		if err != nil {
+			return fail(err)
+		}
+		flt.tags = t
+	}
+	now := time.Now()
+	for _, t := range []struct {
+		s   string
+		dst *time.Time
+	}{{*since, &flt.since}, {*until, &flt.until}} {
+		if t.s == "" {
+			continue
+		}
+		v, err := parseTime(t.s, now, loc)
+		if err != nil {
+			return fail(err)
+		}
+		*t.dst = v
+	}
+	flt.preds = preds
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	rnd, err := newRenderer(out, *format, loc)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Records of files followed are written one at a time.
+	var mu sync.Mutex
+	code := 0
+	handle := func(name string, rec reader.Record, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			fmt.Fprintf(stderr, "alog: %s: %v\n", name, err)
+			// A record with an invalid time is still shown.
+			var le *reader.LineError
+			switch {
+			case errors.Is(err, reader.ErrTime):
+			case errors.As(err, &le):
+				summary.corrupt++
+				return
+			default:
+				code = 1
+				return
+			}
+		}
+		if !flt.match(&rec) {
+			return
+		}
+		summary.add(&rec)
+		if !*quiet {
+			if err := rnd.render(&rec); err != nil {
+				fmt.Fprintln(stderr, "alog:", err)
+				code = 1
+			}
+			if *follow {
+				out.Flush()
+			}
+		}
+	}
+
+	cfg := reader.Config{Location: loc, TimeMs: *timeMs}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var wg sync.WaitGroup
+	for _, name := range files {
+		var r io.Reader = stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				fmt.Fprintln(stderr, "alog:", err)
+				code = 1
+				continue
+			}
+			r = f
+			if *follow {
+				fr := &followReader{name: name, f: f, done: done, poll: 250 * time.Millisecond}
+				defer fr.Close()
+				r = fr
+			} else {
+				defer f.Close()
+			}
+		}
+		if *follow {
+			wg.Add(1)
+			go func(name string, r io.Reader) {
+				defer wg.Done()
+				readAll(name, r, cfg, handle)
+			}(name, r)
+			continue
+		}
+		readAll(name, r, cfg, handle)
+	}
+	wg.Wait()
+
+	if *sum {
+		summary.print(out)
+	}
+	return code
+}
+
+// readAll reads all records of r, and calls fn for each record or error.
+func readAll(name string, r io.Reader, cfg reader.Config, fn func(string, reader.Record, error)) {
+	rd := reader.New(r, cfg)
+	for {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			return
+		}
+		fn(name, rec, err)
+		// Corrupt lines are skipped, but other errors stop reading.
+		var le *reader.LineError
+		if err != nil && !errors.As(err, &le) {
+			return
+		}
+	}
+}
+
+// summary counts records per level and tag.
+type summary struct {
+	total, corrupt int
+	levels         [alog.FatalLevel + 1]int
+	tags           map[string]int
+}
+
+func newSummary() *summary {
+	return &summary{tags: make(map[string]int)}
+}
+
+func (s *summary) add(rec *reader.Record) {
+	s.total++
+	if rec.Level <= alog.FatalLevel {
+		s.levels[rec.Level]++
+	}
+	for i := 0; i < len(rec.Tags); i++ {
+		s.tags[rec.Tags[i]]++
+	}
+}
+
+// print prints counts; levels in order and tags by name.
+func (s *summary) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "TOTAL\t%d\n", s.total)
+	if s.corrupt > 0 {
+		fmt.Fprintf(tw, "CORRUPT\t%d\n", s.corrupt)
+	}
+	fmt.Fprintln(tw, "LEVEL\tCOUNT")
+	for l := alog.Level(0); l <= alog.FatalLevel; l++ {
+		if s.levels[l] == 0 {
+			continue
+		}
+		name := l.Name()
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", name, s.levels[l])
+	}
+	if len(s.tags) > 0 {
+		names := make([]string, 0, len(s.tags))
+		for name := range s.tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(tw, "TAG\tCOUNT")
+		for _, name := range names {
+			fmt.Fprintf(tw, "%s\t%d\n", name, s.tags[name])
+		}
+	}
+	tw.Flush()
+}
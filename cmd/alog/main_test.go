@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const input = `{"date":20210308,"time":203337,"level":"info","tag":["DB"],"message":"query","status":200}
+{"date":20210308,"time":203338,"level":"error","tag":["DB","Net"],"message":"query failed","status":500}
+{"date":20210308,"time":2033
+{"date":20210308,"time":203339,"level":"warn","tag":[],"message":"slow","took":"1.5s"}
+`
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		out  string
+	}{
+		{[]string{"-format", "json", "-level", "warn"}, `{"date":20210308,"time":203338,"level":"error","tag":["DB","Net"],"message":"query failed","status":500}` + "\n" +
+			`{"date":20210308,"time":203339,"level":"warn","tag":[],"message":"slow","took":"1.5s"}` + "\n"},
+		{[]string{"-format", "text", "-tag", "DB+!Net"}, "2021/03/08 20:33:37.000 INF [DB] query // status=200\n"},
+		{[]string{"-format", "logfmt", "-utc", "-where", "took>1s"}, "ts=2021-03-08T20:33:39Z level=warn msg=slow took=1.5s\n"},
+		{[]string{"-q", "-summary", "-since", "2021-03-08 20:33:38"}, "TOTAL    2\nCORRUPT  1\nLEVEL    COUNT\nwarn     1\nerror    1\nTAG      COUNT\nDB       1\nNet      1\n"},
+	} {
+		var out, errOut bytes.Buffer
+		if code := run(tc.args, strings.NewReader(input), &out, &errOut, nil); code != 0 {
+			t.Errorf("unexpected code for %v: %d, %s", tc.args, code, errOut.String())
+		}
+		if out.String() != tc.out {
+			t.Errorf("unexpected output for %v:\n%s", tc.args, out.String())
+		}
+		if !strings.Contains(errOut.String(), "line 3: reader: truncated line") {
+			t.Errorf("unexpected error output: %s", errOut.String())
+		}
+	}
+
+	var errOut bytes.Buffer
+	if code := run([]string{"-level", "loud"}, nil, ioutil.Discard, &errOut, nil); code != 2 {
+		t.Errorf("unexpected code for an invalid level: %d", code)
+	}
+}
+
+func TestRun_Follow(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := ioutil.WriteFile(name, []byte(input[:strings.Index(input, "\n")+1]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var out syncBuffer
+	exit := make(chan int)
+	go func() {
+		exit <- run([]string{"-f", "-format", "json", name}, nil, &out, ioutil.Discard, done)
+	}()
+
+	// Append a line, in two writes, while following.
+	time.Sleep(100 * time.Millisecond)
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"level":"warn",`)
+	time.Sleep(300 * time.Millisecond)
+	f.WriteString(`"message":"later"}` + "\n")
+	f.Close()
+
+	wait := func(s string) {
+		deadline := time.Now().Add(3 * time.Second)
+		for !strings.Contains(out.String(), s) && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	wait("later")
+
+	// Rotated by renaming, the new file is followed.
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, []byte(`{"level":"info","message":"rotated"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wait("rotated")
+	close(done)
+	<-exit
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 3 || lines[1] != `{"level":"warn","message":"later"}` ||
+		lines[2] != `{"level":"info","message":"rotated"}` {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
+
+func TestRun_ManyTags(t *testing.T) {
+	// More tags than a bucket can hold are shown.
+	var in strings.Builder
+	for i := 0; i < 70; i++ {
+		fmt.Fprintf(&in, `{"level":"info","tag":["T%d"],"message":"m"}`+"\n", i)
+	}
+	var out bytes.Buffer
+	if code := run([]string{"-format", "text"}, strings.NewReader(in.String()), &out, ioutil.Discard, nil); code != 0 {
+		t.Fatalf("unexpected code: %d", code)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 70 || lines[69] != "INF [T69] m" {
+		t.Errorf("unexpected output: %q", lines[len(lines)-1])
+	}
+}
+
+// syncBuffer is a buffer which can be read while written.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"github.com/gonyyi/alog/reader"
+	"io"
+	"time"
+)
+
+// formats are output formats other than json.
+var formats = map[string]func() alog.Formatter{
+	"color":  func() alog.Formatter { return ext.NewFormatterTerminalColor() },
+	"text":   func() alog.Formatter { return ext.NewFormatterTerminal() },
+	"logfmt": func() alog.Formatter { return ext.NewFormatterLogfmt(time.RFC3339Nano) },
+	"otel":   func() alog.Formatter { return ext.NewFormatterOTel() },
+}
+
+// renderer writes records in an output format. Records are
+// re-rendered through an ext formatter, or written as is for json.
+type renderer struct {
+	w      io.Writer
+	fmtr   alog.Formatter // fmtr is nil for json
+	flag   alog.Flag
+	loc    *time.Location // loc is the time zone to show the time
+	bucket alog.TagBucket // bucket has tags of the record being rendered
+	buf    []byte
+}
+
+// newRenderer returns a renderer of the format.
+func newRenderer(w io.Writer, format string, loc *time.Location) (*renderer, error) {
+	r := &renderer{
+		w:    w,
+		loc:  loc,
+		flag: alog.WithDate | alog.WithTimeMs | alog.WithLevel | alog.WithTag,
+	}
+	if format == "json" {
+		return r, nil
+	}
+	fn, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	r.fmtr = fn()
+	return r, nil
+}
+
+// render writes the record.
+func (r *renderer) render(rec *reader.Record) error {
+	if r.fmtr == nil {
+		r.buf = append(append(r.buf[:0], rec.Raw...), '\n')
+		_, err := r.w.Write(r.buf)
+		return err
+	}
+
+	// A bucket holds 64 names at most, so it is rebuilt for each
+	// record with its own tags only.
+	r.bucket = alog.TagBucket{}
+	var tag alog.Tag
+	for i := 0; i < len(rec.Tags); i++ {
+		tag |= r.bucket.MustGetTag(rec.Tags[i])
+	}
+	// Records without time won't show the time.
+	flag := r.flag
+	if rec.Time.IsZero() {
+		flag &^= alog.WithDate | alog.WithTimeMs
+	}
+	r.fmtr.Init(alog.Discard{}, flag, &r.bucket)
+
+	f := r.fmtr
+	r.buf = f.Begin(r.buf[:0])
+	r.buf = f.AddTime(r.buf, rec.Time.In(r.loc))
+	r.buf = f.AddLevel(r.buf, rec.Level)
+	r.buf = f.AddTag(r.buf, tag)
+	if rec.Message != "" {
+		r.buf = f.AddMsg(r.buf, rec.Message)
+	}
+	r.buf = f.AddKVs(r.buf, rec.Fields)
+	r.buf = f.End(r.buf)
+	_, err := r.w.Write(r.buf)
+	return err
+}
@@ -0,0 +1,101 @@
+package alog
+
+import "context"
+
+// ctxKey is a type for keys of values alog stores in a context.
+type ctxKey uint8
+
+const (
+	ctxKeyLogger ctxKey = iota + 1 // ctxKeyLogger is for a Logger stored by NewContext
+	ctxKeyBound                    // ctxKeyBound is for fields stored by ContextWith
+)
+
+// NewContext returns a copy of ctx with the logger stored in it.
+// Use FromContext to retrieve it.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, ctxKeyLogger, l)
+}
+
+// FromContext returns a logger stored in ctx by NewContext.
+// If no logger is found, def will be returned.
+func FromContext(ctx context.Context, def Logger) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKeyLogger).(Logger); ok {
+			return l
+		}
+	}
+	return def
+}
+
+// ContextWith returns a copy of ctx with fields bound to it. Fields already
+// bound to ctx will be kept. Entry.Ctx will add those fields to the entry.
+//   eg. ctx = alog.ContextWith(ctx, func(e *alog.Entry) *alog.Entry {
+//           return e.Str("request_id", id)
+//       })
+//       al.Info().Ctx(ctx).Writes("ok")
+func ContextWith(ctx context.Context, fn EntryFn) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if fn == nil {
+		return ctx
+	}
+	e := fn(&Entry{})
+	if e == nil || len(e.kvs) == 0 {
+		return ctx
+	}
+	b := &bound{}
+	if prev, ok := ctx.Value(ctxKeyBound).(*bound); ok {
+		b.kvs = append(b.kvs, prev.kvs...)
+	}
+	b.kvs = append(b.kvs, e.kvs...)
+	return context.WithValue(ctx, ctxKeyBound, b)
+}
+
+// CtxValue returns a CtxFn which adds the value of key in a context
+// as name. The value will be added by Entry.Any, and nothing will
+// be added when the value is not found.
+//   eg. al = al.WithCtxFn(alog.CtxValue(myRequestIDKey, "request_id"))
+func CtxValue(key interface{}, name string) CtxFn {
+	return func(ctx context.Context, e *Entry) *Entry {
+		if v := ctx.Value(key); v != nil {
+			return e.Any(name, v)
+		}
+		return e
+	}
+}
+
+// WithCtxFn will return a new Logger with context extractors added.
+// Entry.Ctx will run them in order to pull values from a context.
+func (l Logger) WithCtxFn(fns ...CtxFn) Logger {
+	if len(fns) == 0 {
+		return l
+	}
+	ctxFns := make([]CtxFn, 0, len(l.ctxFns)+len(fns))
+	ctxFns = append(ctxFns, l.ctxFns...)
+	for i := 0; i < len(fns); i++ {
+		if fns[i] != nil {
+			ctxFns = append(ctxFns, fns[i])
+		}
+	}
+	l.ctxFns = ctxFns
+	return l
+}
+
+// Ctx adds fields bound to ctx by ContextWith, then runs
+// the logger's context extractors added by WithCtxFn.
+func (e *Entry) Ctx(ctx context.Context) *Entry {
+	if e == nil || ctx == nil {
+		return e
+	}
+	if b, ok := ctx.Value(ctxKeyBound).(*bound); ok {
+		e.kvs = append(e.kvs, b.kvs...)
+	}
+	for i := 0; i < len(e.info.ctxFns) && e != nil; i++ {
+		e = e.info.ctxFns[i](ctx, e)
+	}
+	return e
+}
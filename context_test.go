@@ -0,0 +1,60 @@
+package alog_test
+
+import (
+	"context"
+	"github.com/gonyyi/alog"
+	"testing"
+)
+
+type fakeCtxKey string
+
+func TestContext(t *testing.T) {
+	reset()
+
+	// Logger in a context
+	{
+		ctx := alog.NewContext(context.Background(), log.With(func(e *alog.Entry) *alog.Entry {
+			return e.Str("svc", "api")
+		}))
+		l := alog.FromContext(ctx, log)
+		l.Info(0).Writes("done")
+		check(t, `{"level":"info","tag":[],"message":"done","svc":"api"}`)
+
+		l = alog.FromContext(context.Background(), log)
+		l.Info(0).Writes("done")
+		check(t, `{"level":"info","tag":[],"message":"done"}`)
+	}
+
+	// Fields in a context
+	{
+		ctx := alog.ContextWith(context.Background(), func(e *alog.Entry) *alog.Entry {
+			return e.Str("request_id", "r1")
+		})
+		ctx = alog.ContextWith(ctx, func(e *alog.Entry) *alog.Entry {
+			return e.Int("user_id", 2)
+		})
+		log.Info(0).Ctx(ctx).Int("a", 1).Writes("done")
+		check(t, `{"level":"info","tag":[],"message":"done","request_id":"r1","user_id":2,"a":1}`)
+
+		// nil entry should be fine
+		log.Debug(0).Ctx(ctx).Writes("done")
+		check(t, ``)
+	}
+
+	// Context extractors
+	{
+		tmp := log
+		log = log.WithCtxFn(
+			alog.CtxValue(fakeCtxKey("trace"), "trace_id"),
+			func(ctx context.Context, e *alog.Entry) *alog.Entry {
+				return e.Bool("extracted", true)
+			})
+		ctx := context.WithValue(context.Background(), fakeCtxKey("trace"), "abc")
+		log.Info(0).Ctx(ctx).Writes("done")
+		check(t, `{"level":"info","tag":[],"message":"done","trace_id":"abc","extracted":true}`)
+
+		log.Info(0).Ctx(context.Background()).Writes("done")
+		check(t, `{"level":"info","tag":[],"message":"done","extracted":true}`)
+		log = tmp
+	}
+}
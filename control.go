@@ -7,6 +7,10 @@ type control struct {
 	Fn     ControlFn
 	Level  Level
 	Tags   Tag
+
+	// Sampler, if set, will be consulted after the level/tag
+	// check (or Fn) passes, before an Entry is fetched. See NewSampler.
+	Sampler *Sampler
 }
 
 func newControl() control {
@@ -31,6 +35,28 @@ func (c control) Check(lvl Level, tag Tag) bool {
 	return false
 }
 
+// Sample will check if the entry should be logged by the Sampler.
+// This returns true if no Sampler is set.
+func (c control) Sample(lvl Level, tag Tag) bool {
+	if c.Sampler != nil {
+		return c.Sampler.Check(lvl, tag)
+	}
+	return true
+}
+
+// SampleWith is Sample with the time from the clock, such as Logger.Clock.
+// If the clock is nil, time.Now will be used. The clock is called only
+// when a Sampler is set.
+func (c control) SampleWith(lvl Level, tag Tag, clock ClockFn) bool {
+	if c.Sampler == nil {
+		return true
+	}
+	if clock == nil {
+		return c.Sampler.Check(lvl, tag)
+	}
+	return c.Sampler.CheckAt(lvl, tag, clock())
+}
+
 // CheckFn will check if level and tag given is good to be printed.
 func (c control) CheckFn(lvl Level, tag Tag) (bool, bool) {
 	if c.Fn != nil {
@@ -0,0 +1,239 @@
+package alog
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// dedupMaxKeys is the default maximum number of entries a Dedup tracks.
+const dedupMaxKeys = 1024
+
+// dedupKey identifies duplicated entries. kvh is a hash of values of
+// the keys selected by Dedup.
+type dedupKey struct {
+	level Level
+	tag   Tag
+	msg   string
+	kvh   uint64
+}
+
+// dedupItem holds the time of the last entry written and the number of
+// entries suppressed since then. Info of the first entry suppressed is
+// kept to write the summary to the same outputs.
+type dedupItem struct {
+	last  int64
+	count int64
+	info  entryInfo
+}
+
+// dedupSummary is a summary of entries suppressed to be written.
+type dedupSummary struct {
+	k    dedupKey
+	n    int64
+	info entryInfo
+}
+
+// Dedup suppresses duplicated entries. Entries are duplicated when they have
+// the same level, tag, message, and values of selected keys. The first entry
+// will be written, and the following duplicates within the Window will be
+// suppressed. Once the Window closed, a summary with the level, tag, message
+// and `repeated` key holding the number of entries suppressed will be written
+// to the same outputs before the next entry of the logger, even if it's not
+// a duplicate. Summaries are written by the goroutine logging, as writers
+// may not be safe for concurrent use. When no more entries are logged,
+// use Flush to write summaries not yet reported, such as before closing.
+// Windows are by the time of entries, which is from Logger.Clock if set.
+// Fatal level entries are never suppressed.
+//   eg. al.Dedup = alog.NewDedup(time.Second, "host")
+type Dedup struct {
+	Window  time.Duration // Window is the interval of the summary
+	MaxKeys int           // MaxKeys is the maximum number of entries to track
+
+	keys  []string
+	mu    sync.Mutex
+	items map[dedupKey]*dedupItem
+	sweep int64
+	due   int64 // due is the earliest end of windows with entries suppressed
+}
+
+// NewDedup returns a Dedup with the window. Keys, if given, will be
+// used in addition to level, tag and message to tell entries apart.
+func NewDedup(window time.Duration, keys ...string) *Dedup {
+	return &Dedup{
+		Window:  window,
+		MaxKeys: dedupMaxKeys,
+		keys:    keys,
+		items:   make(map[dedupKey]*dedupItem),
+	}
+}
+
+// check returns whether the entry at t should be written. Summaries
+// of windows closed are written first. Info is kept to write
+// the summary of the entry when it's suppressed.
+func (d *Dedup) check(info *entryInfo, t time.Time, level Level, tag Tag, msg string, kvs []KeyValue) bool {
+	k := dedupKey{level: level, tag: tag, msg: msg}
+	if len(d.keys) > 0 && level != FatalLevel {
+		k.kvh = d.hash(kvs)
+	}
+	now := t.UnixNano()
+
+	d.mu.Lock()
+	var sums []dedupSummary
+	if d.due != 0 && now >= d.due {
+		sums = d.expired(now)
+	}
+	ok := level == FatalLevel || d.track(info, k, now)
+	d.mu.Unlock()
+
+	for i := 0; i < len(sums); i++ {
+		sums[i].write()
+	}
+	return ok
+}
+
+// track returns whether the entry of k should be written, and
+// counts it if suppressed.
+func (d *Dedup) track(info *entryInfo, k dedupKey, now int64) bool {
+	if item, ok := d.items[k]; ok {
+		if now-item.last < int64(d.Window) {
+			item.count++
+			if item.count == 1 {
+				item.info = *info
+				if end := item.last + int64(d.Window); d.due == 0 || end < d.due {
+					d.due = end
+				}
+			}
+			return false
+		}
+		// nothing was suppressed; start a new window.
+		item.last = now
+		return true
+	}
+
+	if len(d.items) >= d.MaxKeys {
+		d.evict(now)
+		if len(d.items) >= d.MaxKeys {
+			// too many unique entries; do not track.
+			return true
+		}
+	}
+	d.items[k] = &dedupItem{last: now}
+	return true
+}
+
+// expired removes items with entries suppressed and the window closed,
+// and returns their summaries. It also sets when the next is due.
+func (d *Dedup) expired(now int64) []dedupSummary {
+	var sums []dedupSummary
+	d.due = 0
+	for k, item := range d.items {
+		if item.count == 0 {
+			continue
+		}
+		end := item.last + int64(d.Window)
+		if now >= end {
+			sums = append(sums, dedupSummary{k: k, n: item.count, info: item.info})
+			delete(d.items, k)
+			continue
+		}
+		if d.due == 0 || end < d.due {
+			d.due = end
+		}
+	}
+	return sums
+}
+
+// write writes the summary with the info of the entries suppressed.
+func (s *dedupSummary) write() {
+	e := pool.Get().(*Entry)
+	e.info = s.info
+	// The summary isn't suppressed, and caller and stack
+	// of the entry writing it are not of the summary.
+	e.info.dedup = nil
+	e.info.flag &^= fHasCaller
+	e.info.sflag &^= fHasCaller
+	e.info.stack = 0
+	e.level, e.tag, e.at = s.k.level, s.k.tag, time.Time{}
+	e.buf, e.kvs, e.sub = e.buf[:0], e.kvs[:0], e.sub[:0]
+	e.kvs = append(e.kvs, KeyValue{Key: "repeated", Vtype: KvInt, Vint: s.n})
+	e.write(s.k.msg)
+}
+
+// evict removes items with nothing suppressed and the window passed.
+// Items with suppressed entries are removed when their summaries are
+// written after the window closed.
+func (d *Dedup) evict(now int64) {
+	if now-d.sweep < int64(d.Window) {
+		return
+	}
+	d.sweep = now
+	for k, item := range d.items {
+		if item.count == 0 && now-item.last >= int64(d.Window) {
+			delete(d.items, k)
+		}
+	}
+}
+
+// hash returns FNV-1a hash of values of the selected keys.
+func (d *Dedup) hash(kvs []KeyValue) uint64 {
+	const prime = 1099511628211
+	h := uint64(14695981039346656037)
+	addStr := func(s string) {
+		for i := 0; i < len(s); i++ {
+			h = (h ^ uint64(s[i])) * prime
+		}
+	}
+	addInt := func(v uint64) {
+		for i := 0; i < 8; i++ {
+			h = (h ^ (v & 0xff)) * prime
+			v >>= 8
+		}
+	}
+	for i := 0; i < len(kvs); i++ {
+		for j := 0; j < len(d.keys); j++ {
+			if kvs[i].Key != d.keys[j] {
+				continue
+			}
+			addStr(kvs[i].Key)
+			addInt(uint64(kvs[i].Vtype))
+			addInt(uint64(kvs[i].Vint))
+			addInt(math.Float64bits(kvs[i].Vf64))
+			addStr(kvs[i].Vstr)
+			if kvs[i].Vbool {
+				addInt(1)
+			}
+			if kvs[i].Verr != nil {
+				addStr(kvs[i].Verr.Error())
+			}
+		}
+	}
+	return h
+}
+
+// Flush writes a summary of each entry suppressed but not yet reported
+// to the logger, with its level, tag, message and `repeated` key.
+// Values of selected keys are not kept, hence won't be in the summary.
+// This can be called periodically, or before closing the logger.
+func (d *Dedup) Flush(l *Logger) {
+	var sums []dedupSummary
+
+	d.mu.Lock()
+	for k, item := range d.items {
+		if item.count > 0 {
+			sums = append(sums, dedupSummary{k: k, n: item.count})
+		}
+		// summaries will be written as new entries.
+		delete(d.items, k)
+	}
+	d.due = 0
+	d.mu.Unlock()
+
+	// Summaries of entries differ only by selected keys look the same,
+	// so write them without Dedup.
+	sl := *l
+	sl.Dedup = nil
+	for i := 0; i < len(sums); i++ {
+		sl.Log(sums[i].k.level, sums[i].k.tag).Int64("repeated", sums[i].n).Writes(sums[i].k.msg)
+	}
+}
@@ -0,0 +1,86 @@
+package alog_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2021, 3, 8, 20, 33, 37, 0, time.UTC)
+	l := alog.New(&buf)
+	l.Flag = alog.WithLevel | alog.WithTag
+	l.Clock = func() time.Time { return now }
+	tagDB := l.NewTag("DB")
+	l.Dedup = alog.NewDedup(time.Second, "host")
+	err := errors.New("conn refused")
+
+	for i := 0; i < 5; i++ {
+		l.Error(tagDB).Err(err).Str("host", "a").Writes("connect failed")
+		l.Error(tagDB).Err(err).Str("host", "b").Writes("connect failed")
+	}
+	l.Warn(tagDB).Writes("connect failed")
+	exp := `{"level":"error","tag":["DB"],"message":"connect failed","error":"conn refused","host":"a"}
+{"level":"error","tag":["DB"],"message":"connect failed","error":"conn refused","host":"b"}
+{"level":"warn","tag":["DB"],"message":"connect failed"}
+`
+	if buf.String() != exp {
+		t.Errorf("Dedup 1 // exp=<%s>, act=<%s>", exp, buf.String())
+	}
+	buf.Reset()
+
+	// Once the window closed, summaries are written before the next entry,
+	// and reported items are removed; the next one starts a new window.
+	now = now.Add(time.Second)
+	if buf.Len() != 0 {
+		t.Errorf("Dedup 2 // nothing should be written without entries: %s", buf.String())
+	}
+	l.Error(tagDB).Err(err).Str("host", "a").Writes("connect failed")
+	sum := `{"level":"error","tag":["DB"],"message":"connect failed","repeated":4}` + "\n"
+	exp = `{"level":"error","tag":["DB"],"message":"connect failed","error":"conn refused","host":"a"}` + "\n"
+	if act := buf.String(); act != sum+sum+exp {
+		t.Errorf("Dedup 3 // exp=<%s>, act=<%s>", sum+sum+exp, act)
+	}
+	buf.Reset()
+
+	// Flush writes summaries not yet reported, using a custom formatter as well.
+	l = l.Ext(ext.LogFmt.Text())
+	l.Flag = alog.WithLevel | alog.WithTag
+	l.Error(tagDB).Err(err).Str("host", "a").Writes("connect failed")
+	l.Error(tagDB).Err(err).Str("host", "a").Writes("connect failed")
+	l.Dedup.Flush(&l)
+	exp = "ERR [DB] connect failed // repeated=2\n"
+	if act := buf.String(); act != exp {
+		t.Errorf("Dedup 4 // exp=<%s>, act=<%s>", exp, act)
+	}
+}
+
+func TestDedup_MaxKeys(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2021, 3, 8, 20, 33, 37, 0, time.UTC)
+	l := alog.New(&buf)
+	l.Flag = alog.WithLevel
+	l.Clock = func() time.Time { return now }
+	l.Dedup = alog.NewDedup(time.Second)
+	l.Dedup.MaxKeys = 2
+
+	for i := 0; i < 3; i++ {
+		l.Info(0).Writes("a")
+		l.Info(0).Writes("b")
+	}
+	now = now.Add(time.Second)
+	// Keys reported are removed, so new ones can be tracked.
+	for i := 0; i < 3; i++ {
+		l.Info(0).Writes("c")
+	}
+	l.Dedup.Flush(&l)
+	if act := buf.String(); strings.Count(act, `"repeated":2}`) != 3 || strings.Count(act, `"message":"c"`) != 2 ||
+		!strings.HasSuffix(act, `{"level":"info","message":"c","repeated":2}`+"\n") {
+		t.Errorf("Dedup MaxKeys // act=<%s>", act)
+	}
+}
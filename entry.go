@@ -2,6 +2,7 @@ package alog
 
 import (
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -17,26 +18,81 @@ type KeyValue struct {
 	Vstr  string
 	Vbool bool
 	Verr  error
+	Vkvs  []KeyValue
+	Vbyte []byte
+	Vany  interface{}
 }
 
-// entryInfo is 56 bytes
+// Uint returns the value of KvUint item.
+func (kv KeyValue) Uint() uint64 {
+	return uint64(kv.Vint)
+}
+
+// Duration returns the value of KvDuration item.
+func (kv KeyValue) Duration() time.Duration {
+	return time.Duration(kv.Vint)
+}
+
+// Time returns the value of KvTime item. KvTime is stored as
+// unix seconds in Vint, nanoseconds in Vf64, and *time.Location
+// in Vany, so any time can be kept. A zero time will have nil for Vany.
+func (kv KeyValue) Time() time.Time {
+	if loc, ok := kv.Vany.(*time.Location); ok && loc != nil {
+		return time.Unix(kv.Vint, int64(kv.Vf64)).In(loc)
+	}
+	return time.Time{}
+}
+
+// entryInfo is 192 bytes
 type entryInfo struct {
 	flag    Flag
 	tbucket *TagBucket
 	w       Writer
 	orFmtr  Formatter
+	bound   *bound
+	skip    int
+	stack   Level
+	ctxFns  []CtxFn
+	dedup   *Dedup
+	hooks   []HookFn
+	main    bool // main is true when the logger's own output allows the entry
+	sinks   []Sink
+	sflag   Flag
+	layout  *layout
+	loc     *time.Location
+	clock   ClockFn
 	// w       io.Writer
 }
 
+// now returns the time from the logger's Clock, or time.Now.
+func (i *entryInfo) now() time.Time {
+	if i.clock != nil {
+		return i.clock()
+	}
+	return time.Now()
+}
+
+// segment is a range of Entry.buf formatted by the built-in formatter
+// with the flag. Outputs with the same flag will reuse it.
+type segment struct {
+	flag     Flag
+	from, to int
+}
+
 // Entry is a log Entry will be used with a entryPool to
 // reuse the resource.
-// Entry is 120 bytes, use pointer.
+// Entry is 664 bytes, use pointer.
 type Entry struct {
 	buf   []byte
 	level Level
 	tag   Tag
 	kvs   []KeyValue
 	info  entryInfo
+	sub   []KeyValue // sub holds nested items such as stack frames
+	pcs   [entry_stack_size]uintptr
+	segs  [entry_seg_size]segment
+	nseg  int
+	at    time.Time // at is the time of the entry; see Entry.At
 }
 
 // Writes will finalize the log message, format it, and
@@ -67,127 +123,253 @@ func (e *Entry) write(msg string) {
 		// make sure this will be put back to memory.
 		defer pool.Put(e)
 
+		// Run hooks; any of them can veto the entry.
+		for i := 0; i < len(e.info.hooks); i++ {
+			if e.info.hooks[i](e, e.level, e.tag, msg, e.kvs) == false {
+				if e.level == FatalLevel {
+					os.Exit(1)
+				}
+				return
+			}
+		}
+
+		// Suppress duplicated entries; summaries of entries
+		// suppressed before will be written first.
+		// The time of the entry is used for the window.
+		if e.info.dedup != nil {
+			if e.at.IsZero() {
+				e.at = e.info.now()
+			}
+			if !e.info.dedup.check(&e.info, e.at, e.level, e.tag, msg, e.kvs) {
+				return
+			}
+		}
+
+		// Caller needs to be taken here, as Writes/Write is
+		// always called directly from the user's code.
+		var cpc uintptr
+		var cfile string
+		var cline int
+		if (e.info.flag|e.info.sflag)&fHasCaller != 0 {
+			cpc, cfile, cline = caller(e.info.skip)
+		}
+
+		// Attach stack automatically if the level is at or above
+		// the logger's StackLevel, unless Entry.Stack was used.
+		if e.info.stack != 0 && e.level >= e.info.stack && !e.hasStack() {
+			// 2 frames: write <- Writes/Write
+			e.addStack(2 + e.info.skip)
+		}
+
+		// Time is taken once, so all outputs will have the same time.
+		// If set by Entry.At, it will be used instead.
+		if e.at.IsZero() && ((e.info.flag|e.info.sflag)&fHasTime != 0 || e.info.orFmtr != nil || len(e.info.sinks) > 0) {
+			e.at = e.info.now()
+		}
+
 		// if custom formatter exists, use it instead of default formatter.
 		// for default formatter (formatd), it's a concrete function for speed.
 		// rather than using from the interface.
-		if e.info.orFmtr != nil {
-			// CUSTOM FORMATTER
-			e.buf = e.info.orFmtr.Begin(e.buf)
-			e.buf = e.info.orFmtr.AddTime(e.buf)
-			e.buf = e.info.orFmtr.AddLevel(e.buf, e.level)
-			e.buf = e.info.orFmtr.AddTag(e.buf, e.tag)
-			if msg != "" {
-				e.buf = e.info.orFmtr.AddMsg(e.buf, msg)
+		e.nseg = 0
+		if e.info.main {
+			if e.info.orFmtr != nil {
+				e.writeFmtr(e.info.orFmtr, e.info.flag, msg, cpc, cfile, cline)
+			} else if _, ok := e.info.w.(Discard); !ok && e.info.w != nil {
+				from, to := e.formatd(e.info.flag, msg, cpc, cfile, cline)
+				e.info.w.WriteLt(e.buf[from:to], e.level, e.tag)
 			}
-			e.buf = e.info.orFmtr.AddKVs(e.buf, e.kvs)
-			e.buf = e.info.orFmtr.End(e.buf)
-			e.info.orFmtr.Write(e.buf, e.level, e.tag)
-		} else {
-			// BUILT-IN FORMATTER
-			// using dFmt (of formatd)
-			e.buf = dFmt.addBegin(e.buf)
-
-			// APPEND TIME
-			if e.info.flag&fHasTime != 0 {
-				t := time.Now()
-				if (WithUnixTime|WithUnixTimeMs)&e.info.flag != 0 {
-					e.buf = dFmt.addKeyUnsafe(e.buf, "ts")
-					if WithUnixTimeMs&e.info.flag != 0 {
-						e.buf = dFmt.addTimeUnix(e.buf, t.UnixNano()/1e6)
-					} else {
-						e.buf = dFmt.addTimeUnix(e.buf, t.Unix())
-					}
-				} else {
-					if WithUTC&e.info.flag != 0 {
-						t = t.UTC()
-					}
-					if WithDate&e.info.flag != 0 {
-						e.buf = dFmt.addKeyUnsafe(e.buf, "date")
-						y, m, d := t.Date()
-						e.buf = dFmt.addTimeDate(e.buf, y, int(m), d)
-					}
-					if WithDay&e.info.flag != 0 {
-						e.buf = dFmt.addKeyUnsafe(e.buf, "day")
-						e.buf = dFmt.addTimeDay(e.buf, int(t.Weekday()))
-					}
-					if (WithTime|WithTimeMs)&e.info.flag != 0 {
-						e.buf = dFmt.addKeyUnsafe(e.buf, "time")
-						h, m, s := t.Clock()
-						if WithTimeMs&e.info.flag != 0 {
-							e.buf = dFmt.addTimeMs(e.buf, h, m, s, t.Nanosecond())
-						} else {
-							e.buf = dFmt.addTime(e.buf, h, m, s)
-						}
-					}
-				}
+		}
+
+		// WRITE TO SINKS
+		for i := 0; i < len(e.info.sinks); i++ {
+			s := &e.info.sinks[i]
+			if !s.check(e.level, e.tag) {
+				continue
 			}
+			if s.fmtr != nil {
+				e.writeFmtr(s.fmtr, s.Flag, msg, cpc, cfile, cline)
+			} else {
+				from, to := e.formatd(s.Flag, msg, cpc, cfile, cline)
+				s.w.WriteLt(e.buf[from:to], e.level, e.tag)
+			}
+		}
+
+		if e.level == FatalLevel {
+			os.Exit(1)
+		}
+	}
+}
+
+// writeFmtr formats the entry with the custom formatter and writes it.
+// Bound fields and caller will be given to the formatter as leading KeyValues.
+func (e *Entry) writeFmtr(f Formatter, flag Flag, msg string, cpc uintptr, cfile string, cline int) {
+	kvs := e.kvs
+	sub := len(e.sub)
+	if e.info.bound != nil || flag&fHasCaller != 0 {
+		// Leading KeyValues are added to a copy of kvs in e.sub,
+		// so e.kvs stays the same for other outputs.
+		if flag&WithCaller != 0 && cfile != "" {
+			e.sub = append(e.sub, KeyValue{Key: "caller", Vtype: KvString, Vstr: cfile + ":" + strconv.Itoa(cline)})
+		}
+		if flag&WithFunc != 0 {
+			e.sub = append(e.sub, KeyValue{Key: "func", Vtype: KvString, Vstr: callerFunc(cpc)})
+		}
+		if e.info.bound != nil {
+			e.sub = append(e.sub, e.info.bound.kvs...)
+		}
+		e.sub = append(e.sub, e.kvs...)
+		kvs = e.sub[sub:]
+	}
 
-			// APPEND LEVEL
-			if e.info.flag&WithLevel != 0 {
-				e.buf = dFmt.addKeyUnsafe(e.buf, "level")
+	from := len(e.buf)
+	e.buf = f.Begin(e.buf)
+	e.buf = f.AddTime(e.buf, e.located(flag, e.at))
+	e.buf = f.AddLevel(e.buf, e.level)
+	e.buf = f.AddTag(e.buf, e.tag)
+	if msg != "" {
+		e.buf = f.AddMsg(e.buf, msg)
+	}
+	e.buf = f.AddKVs(e.buf, kvs)
+	e.buf = f.End(e.buf)
+	f.Write(e.buf[from:], e.level, e.tag)
+
+	// Custom formatted output won't be reused.
+	e.buf = e.buf[:from]
+	e.sub = e.sub[:sub]
+}
+
+// formatd formats the entry with the built-in formatter and the flag,
+// and returns the range of e.buf. If the entry was already formatted
+// with the same flag, it will be reused.
+func (e *Entry) formatd(flag Flag, msg string, cpc uintptr, cfile string, cline int) (int, int) {
+	for i := 0; i < e.nseg; i++ {
+		if e.segs[i].flag == flag {
+			return e.segs[i].from, e.segs[i].to
+		}
+	}
+
+	lo := e.info.layout
+	if lo == nil {
+		lo = dLayout
+	}
+
+	from := len(e.buf)
+	e.buf = dFmt.addBegin(e.buf)
+
+	// APPEND RESERVED FIELDS in the order of the layout
+	for i := 0; i < len(lo.order); i++ {
+		switch lo.order[i] {
+		case FieldTime:
+			e.addTimed(lo, flag, e.at)
+		case FieldLevel:
+			if flag&WithLevel != 0 {
+				e.buf = append(e.buf, lo.level...)
 				e.buf = dFmt.addLevel(e.buf, e.level)
 			}
-
-			// APPEND TAG
-			if e.info.flag&WithTag != 0 {
-				e.buf = dFmt.addKeyUnsafe(e.buf, "tag")
+		case FieldTag:
+			if flag&WithTag != 0 {
+				e.buf = append(e.buf, lo.tag...)
 				e.buf = dFmt.addTag(e.buf, e.info.tbucket, e.tag)
 			}
-
-			// APPEND MSG
+		case FieldCaller:
+			if flag&WithCaller != 0 && cfile != "" {
+				e.buf = append(e.buf, lo.caller...)
+				e.buf = dFmt.addCaller(e.buf, cfile, cline)
+			}
+			if flag&WithFunc != 0 {
+				e.buf = append(e.buf, lo.fn...)
+				e.buf = dFmt.addValString(e.buf, callerFunc(cpc))
+			}
+		case FieldMessage:
 			if msg != "" {
-				e.buf = dFmt.addKeyUnsafe(e.buf, "message")
+				e.buf = append(e.buf, lo.msg...)
 				e.buf = append(e.buf, '"')
 				e.buf = appendString(e.buf, msg, false)
 				e.buf = append(e.buf, '"', ',')
 			}
+		}
+	}
 
-			// APPEND KEY VALUES
-			for i := 0; i < len(e.kvs); i++ {
-				// Set name
-				e.buf = dFmt.addKey(e.buf, e.kvs[i].Key)
-
-				switch e.kvs[i].Vtype {
-				case KvInt:
-					e.buf = dFmt.addValInt(e.buf, e.kvs[i].Vint)
-				case KvString:
-					if ok, _ := dFmt.isSimpleStr(e.kvs[i].Vstr); ok {
-						e.buf = dFmt.addValStringUnsafe(e.buf, e.kvs[i].Vstr)
-					} else {
-						e.buf = dFmt.addValString(e.buf, e.kvs[i].Vstr)
-					}
-				case KvBool:
-					e.buf = dFmt.addValBool(e.buf, e.kvs[i].Vbool)
-				case KvFloat64:
-					e.buf = dFmt.addValFloat(e.buf, e.kvs[i].Vf64)
-				case KvError:
-					if e.kvs[i].Verr != nil {
-						errStr := e.kvs[i].Verr.Error()
-						if ok, _ := dFmt.isSimpleStr(errStr); ok {
-							e.buf = dFmt.addValStringUnsafe(e.buf, errStr)
-						} else {
-							e.buf = dFmt.addValString(e.buf, errStr)
-						}
-					} else {
-						e.buf = append(e.buf, `null,`...)
-					}
-				default:
-					e.buf = append(e.buf, `null,`...)
-				}
-			}
+	// User fields can be nested under a key of the layout.
+	nested := lo.fields != "" && (len(e.kvs) > 0 || e.info.bound != nil)
+	if nested {
+		e.buf = append(e.buf, lo.fields...)
+	}
 
-			// APPEND FINAL
-			e.buf = dFmt.addEnd(e.buf)
+	// APPEND BOUND KEY VALUES (pre-encoded by Logger.With)
+	if e.info.bound != nil {
+		e.buf = append(e.buf, e.info.bound.buf...)
+	}
 
-			// write to output
-			if e.info.w != nil {
-				// e.logger.w.write(e.buf)
-				// e.info.w.write(e.buf)
-				e.info.w.WriteLt(e.buf, e.level, e.tag)
-			}
+	// APPEND KEY VALUES
+	e.buf = dFmt.addKVs(e.buf, e.kvs)
+
+	if nested {
+		e.buf[len(e.buf)-1] = '}'
+		e.buf = append(e.buf, ',')
+	}
+
+	// APPEND FINAL
+	e.buf = dFmt.addEnd(e.buf)
+
+	if e.nseg < len(e.segs) {
+		e.segs[e.nseg] = segment{flag: flag, from: from, to: len(e.buf)}
+		e.nseg++
+	}
+	return from, len(e.buf)
+}
+
+// located returns t in UTC with WithUTC, or in the logger's Location.
+func (e *Entry) located(flag Flag, t time.Time) time.Time {
+	if WithUTC&flag != 0 {
+		return t.UTC()
+	}
+	if e.info.loc != nil {
+		return t.In(e.info.loc)
+	}
+	return t
+}
+
+// addTimed adds time fields for the built-in formatter.
+func (e *Entry) addTimed(lo *layout, flag Flag, t time.Time) {
+	if flag&fHasTime == 0 {
+		return
+	}
+	t = e.located(flag, t)
+	if fHasTS&flag != 0 {
+		e.buf = append(e.buf, lo.ts...)
+		switch {
+		case WithRFC3339Nano&flag != 0:
+			e.buf = append(AppendRFC3339(append(e.buf, '"'), t, true), '"', ',')
+		case WithRFC3339&flag != 0:
+			e.buf = append(AppendRFC3339(append(e.buf, '"'), t, false), '"', ',')
+		case WithUnixTimeNs&flag != 0:
+			e.buf = dFmt.addTimeUnix(e.buf, t.UnixNano())
+		case WithUnixTimeUs&flag != 0:
+			e.buf = dFmt.addTimeUnix(e.buf, t.UnixNano()/1e3)
+		case WithUnixTimeMs&flag != 0:
+			e.buf = dFmt.addTimeUnix(e.buf, t.UnixNano()/1e6)
+		default:
+			e.buf = dFmt.addTimeUnix(e.buf, t.Unix())
 		}
-		if e.level == FatalLevel {
-			os.Exit(1)
+		return
+	}
+	if WithDate&flag != 0 {
+		e.buf = append(e.buf, lo.date...)
+		y, m, d := t.Date()
+		e.buf = dFmt.addTimeDate(e.buf, y, int(m), d)
+	}
+	if WithDay&flag != 0 {
+		e.buf = append(e.buf, lo.day...)
+		e.buf = dFmt.addTimeDay(e.buf, int(t.Weekday()))
+	}
+	if (WithTime|WithTimeMs)&flag != 0 {
+		e.buf = append(e.buf, lo.time...)
+		h, m, s := t.Clock()
+		if WithTimeMs&flag != 0 {
+			e.buf = dFmt.addTimeMs(e.buf, h, m, s, t.Nanosecond())
+		} else {
+			e.buf = dFmt.addTime(e.buf, h, m, s)
 		}
 	}
 }
@@ -257,6 +439,102 @@ func (e *Entry) Int64(key string, val int64) *Entry {
 	return e
 }
 
+// Uint adds KeyValue item for unsigned integer. This will convert uint to uint64.
+// Uint and Uint64 share same kind (KvUint).
+func (e *Entry) Uint(key string, val uint) *Entry {
+	if e != nil {
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvUint,
+			Key:   key,
+			Vint:  int64(val),
+		})
+	}
+	return e
+}
+
+// Uint64 adds KeyValue item for 64 bit unsigned integer.
+// The value is stored in Vint as is; use KeyValue.Uint() to read it.
+func (e *Entry) Uint64(key string, val uint64) *Entry {
+	if e != nil {
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvUint,
+			Key:   key,
+			Vint:  int64(val),
+		})
+	}
+	return e
+}
+
+// Duration adds KeyValue item for time.Duration.
+// This will be shown as a string such as "1.5s".
+func (e *Entry) Duration(key string, val time.Duration) *Entry {
+	if e != nil {
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvDuration,
+			Key:   key,
+			Vint:  int64(val),
+		})
+	}
+	return e
+}
+
+// Time adds KeyValue item for time.Time.
+// This will be shown as RFC3339 with nanoseconds, or null for a zero time.
+func (e *Entry) Time(key string, val time.Time) *Entry {
+	if e != nil {
+		kv := KeyValue{
+			Vtype: KvTime,
+			Key:   key,
+		}
+		if !val.IsZero() {
+			kv.Vint = val.Unix()
+			kv.Vf64 = float64(val.Nanosecond())
+			kv.Vany = val.Location()
+		}
+		e.kvs = append(e.kvs, kv)
+	}
+	return e
+}
+
+// Bytes adds KeyValue item for a byte slice which will be shown as a string.
+// As the byte slice is not copied, it should not be modified until written.
+func (e *Entry) Bytes(key string, val []byte) *Entry {
+	if e != nil {
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvBytes,
+			Key:   key,
+			Vbyte: val,
+		})
+	}
+	return e
+}
+
+// Hex adds KeyValue item for a byte slice which will be shown as a hex string.
+// As the byte slice is not copied, it should not be modified until written.
+func (e *Entry) Hex(key string, val []byte) *Entry {
+	if e != nil {
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvHex,
+			Key:   key,
+			Vbyte: val,
+		})
+	}
+	return e
+}
+
+// Base64 adds KeyValue item for a byte slice which will be shown as a base64 string.
+// As the byte slice is not copied, it should not be modified until written.
+func (e *Entry) Base64(key string, val []byte) *Entry {
+	if e != nil {
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvBase64,
+			Key:   key,
+			Vbyte: val,
+		})
+	}
+	return e
+}
+
 // Err adds KeyValue for error item.
 func (e *Entry) Err(val error) *Entry {
 	if e != nil {
@@ -269,6 +547,16 @@ func (e *Entry) Err(val error) *Entry {
 	return e
 }
 
+// At sets the time of the entry. This is for replaying past events
+// with their original time. Without it, the time will be taken
+// once when written, from the logger's Clock if set.
+func (e *Entry) At(t time.Time) *Entry {
+	if e != nil {
+		e.at = t
+	}
+	return e
+}
+
 // Ext will take EntryFn and add an entry to it.
 func (e *Entry) Ext(fn EntryFn) *Entry {
 	if e == nil || fn == nil {
@@ -0,0 +1,122 @@
+package alog
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Any adds KeyValue item of any value. Values of alog supported types
+// (such as string, int, time.Time, []string, error, ObjectMarshaler)
+// will take their fast paths and be added as those types.
+// Other values will be added as KvAny, and will be rendered by the
+// formatter. The built-in formatter will use, in order,
+// json.Marshaler, encoding.TextMarshaler, fmt.Stringer, and encoding/json.
+//
+// Note that, unlike the rest of alog, Any may allocate: storing a
+// non-pointer value in an interface allocates, and rendering KvAny
+// by encoding/json or String() allocates as well. Use typed methods
+// when possible.
+func (e *Entry) Any(key string, val interface{}) *Entry {
+	if e == nil {
+		return e
+	}
+	switch v := val.(type) {
+	case string:
+		return e.Str(key, v)
+	case bool:
+		return e.Bool(key, v)
+	case int:
+		return e.Int(key, v)
+	case int8:
+		return e.Int64(key, int64(v))
+	case int16:
+		return e.Int64(key, int64(v))
+	case int32:
+		return e.Int64(key, int64(v))
+	case int64:
+		return e.Int64(key, v)
+	case uint:
+		return e.Uint(key, v)
+	case uint8:
+		return e.Uint64(key, uint64(v))
+	case uint16:
+		return e.Uint64(key, uint64(v))
+	case uint32:
+		return e.Uint64(key, uint64(v))
+	case uint64:
+		return e.Uint64(key, v)
+	case float32:
+		return e.Float(key, float64(v))
+	case float64:
+		return e.Float(key, v)
+	case time.Duration:
+		return e.Duration(key, v)
+	case time.Time:
+		return e.Time(key, v)
+	case []byte:
+		return e.Bytes(key, v)
+	case []string:
+		return e.Strs(key, v)
+	case []int:
+		return e.Ints(key, v)
+	case []float64:
+		return e.Floats(key, v)
+	case []bool:
+		return e.Bools(key, v)
+	case []error:
+		return e.Errs(key, v)
+	case ObjectMarshaler:
+		if isNilPtr(v) {
+			break
+		}
+		return e.Object(key, v)
+	case error:
+		if isNilPtr(v) {
+			break
+		}
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvError,
+			Key:   key,
+			Verr:  v,
+		})
+		return e
+	}
+	// nil, fmt.Stringer, encoding.TextMarshaler, json.Marshaler and others.
+	// A nil pointer will be null, as its methods may dereference it.
+	if val != nil && isNilPtr(val) {
+		val = nil
+	}
+	e.kvs = append(e.kvs, KeyValue{
+		Vtype: KvAny,
+		Key:   key,
+		Vany:  val,
+	})
+	return e
+}
+
+// isNilPtr returns true if v is a nil pointer such as (*T)(nil).
+func isNilPtr(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// addValAny will append a value of KvAny in JSON format.
+func (f formatd) addValAny(dst []byte, val interface{}) []byte {
+	if val == nil || isNilPtr(val) {
+		return append(dst, `null,`...)
+	}
+	switch v := val.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		// encoding/json validates and compacts the output of the marshalers
+	case fmt.Stringer:
+		return f.addValString(dst, v.String())
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return f.addValString(dst, "!ERROR: "+err.Error())
+	}
+	return append(append(dst, b...), ',')
+}
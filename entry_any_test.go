@@ -0,0 +1,89 @@
+package alog_test
+
+import (
+	"errors"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"net"
+	"testing"
+	"time"
+)
+
+type fakeStringer int
+
+func (s fakeStringer) String() string {
+	return "str"
+}
+
+type fakeJSON struct{}
+
+func (fakeJSON) MarshalJSON() ([]byte, error) {
+	return []byte(`{ "a" : 1 }`), nil
+}
+
+type fakePtrStringer struct{ s string }
+
+func (p *fakePtrStringer) String() string {
+	return p.s
+}
+
+type fakePtrErr struct{ s string }
+
+func (p *fakePtrErr) Error() string {
+	return p.s
+}
+
+func TestEntry_Any(t *testing.T) {
+	reset()
+	cfg := struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}{"api", 80}
+
+	log.Info(0).
+		Any("s", "a").
+		Any("i", int32(-1)).
+		Any("u", uint8(1)).
+		Any("f", float32(1.5)).
+		Any("d", time.Second).
+		Any("strs", []string{"a"}).
+		Any("err", errors.New("e")).
+		Any("obj", &fakeReq{Method: "GET", Status: 200}).
+		Any("nil", nil).
+		Any("stringer", fakeStringer(1)).
+		Any("text", net.IPv4(10, 0, 0, 1)).
+		Any("json", fakeJSON{}).
+		Any("cfg", cfg).
+		Writes("done")
+	check(t, `{"level":"info","tag":[],"message":"done","s":"a","i":-1,"u":1,"f":1.5,"d":"1s","strs":["a"],"err":"e","obj":{"method":"GET","status":200},"nil":null,"stringer":"str","text":"10.0.0.1","json":{"a":1},"cfg":{"name":"api","port":80}}`)
+
+	log = log.Ext(ext.LogFmt.Text())
+	log.Info(0).
+		Any("nil", nil).
+		Any("stringer", fakeStringer(1)).
+		Any("cfg", cfg).
+		Writes("done")
+	check(t, `INF [] done // nil=null, stringer=str, cfg={"name":"api","port":80}`)
+
+	// Fast paths should not allocate when no boxing is needed.
+	al := alog.New(nil)
+	req := &fakeReq{Method: "GET", Status: 200}
+	if n := testing.AllocsPerRun(100, func() {
+		al.Info(0).Any("s", "a").Any("obj", req).Write()
+	}); n != 0 && !raceEnabled {
+		t.Errorf("unexpected allocation: %f", n)
+	}
+
+	// Nil pointers will be null without calling their methods.
+	reset()
+	log.Info(0).
+		Any("obj", (*fakeReq)(nil)).
+		Any("stringer", (*fakePtrStringer)(nil)).
+		Any("err", (*fakePtrErr)(nil)).
+		Writes("nil")
+	check(t, `{"level":"info","tag":[],"message":"nil","obj":null,"stringer":null,"err":null}`)
+
+	log = log.Ext(ext.LogFmt.Text())
+	log.Info(0).Any("stringer", (*fakePtrStringer)(nil)).Writes("nil")
+	check(t, `INF [] nil // stringer=null`)
+}
@@ -0,0 +1,93 @@
+package alog
+
+// Array items are stored in the pooled sub slice of the Entry,
+// and KeyValue.Vkvs will point to the part of it. Each item
+// has its own scalar type such as KvString or KvInt.
+
+// subFrom returns items of sub slice added since start.
+// Capacity is limited so that it won't be overwritten by appends.
+func (e *Entry) subFrom(start int) []KeyValue {
+	return e.sub[start:len(e.sub):len(e.sub)]
+}
+
+// Strs adds KeyValue item of a string slice.
+func (e *Entry) Strs(key string, val []string) *Entry {
+	if e != nil {
+		start := len(e.sub)
+		for i := 0; i < len(val); i++ {
+			e.sub = append(e.sub, KeyValue{Vtype: KvString, Vstr: val[i]})
+		}
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvStrs,
+			Key:   key,
+			Vkvs:  e.subFrom(start),
+		})
+	}
+	return e
+}
+
+// Ints adds KeyValue item of an int slice.
+// Each item will be converted to int64 (KvInt).
+func (e *Entry) Ints(key string, val []int) *Entry {
+	if e != nil {
+		start := len(e.sub)
+		for i := 0; i < len(val); i++ {
+			e.sub = append(e.sub, KeyValue{Vtype: KvInt, Vint: int64(val[i])})
+		}
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvInts,
+			Key:   key,
+			Vkvs:  e.subFrom(start),
+		})
+	}
+	return e
+}
+
+// Floats adds KeyValue item of a float64 slice.
+func (e *Entry) Floats(key string, val []float64) *Entry {
+	if e != nil {
+		start := len(e.sub)
+		for i := 0; i < len(val); i++ {
+			e.sub = append(e.sub, KeyValue{Vtype: KvFloat64, Vf64: val[i]})
+		}
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvFloats,
+			Key:   key,
+			Vkvs:  e.subFrom(start),
+		})
+	}
+	return e
+}
+
+// Bools adds KeyValue item of a bool slice.
+func (e *Entry) Bools(key string, val []bool) *Entry {
+	if e != nil {
+		start := len(e.sub)
+		for i := 0; i < len(val); i++ {
+			e.sub = append(e.sub, KeyValue{Vtype: KvBool, Vbool: val[i]})
+		}
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvBools,
+			Key:   key,
+			Vkvs:  e.subFrom(start),
+		})
+	}
+	return e
+}
+
+// Errs adds KeyValue item of an error slice.
+// Nil errors will be shown as null.
+func (e *Entry) Errs(key string, val []error) *Entry {
+	if e != nil {
+		start := len(e.sub)
+		for i := 0; i < len(val); i++ {
+			e.sub = append(e.sub, KeyValue{Vtype: KvError, Verr: val[i]})
+		}
+		e.kvs = append(e.kvs, KeyValue{
+			Vtype: KvErrs,
+			Key:   key,
+			Vkvs:  e.subFrom(start),
+		})
+	}
+	return e
+}
@@ -0,0 +1,39 @@
+package alog_test
+
+import (
+	"errors"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"testing"
+)
+
+func TestEntry_Array(t *testing.T) {
+	reset()
+	log.Info(0).
+		Strs("strs", []string{"a", "b\"c"}).
+		Ints("ints", []int{1, -2}).
+		Floats("floats", []float64{1.5}).
+		Bools("bools", []bool{true, false}).
+		Errs("errs", []error{errors.New("e1"), nil}).
+		Strs("empty", nil).
+		Writes("done")
+	check(t, `{"level":"info","tag":[],"message":"done","strs":["a","b\"c"],"ints":[1,-2],"floats":[1.5],"bools":[true,false],"errs":["e1",null],"empty":[]}`)
+
+	log = log.Ext(ext.LogFmt.Text())
+	log.Info(0).
+		Strs("strs", []string{"a", "b", "c"}).
+		Ints("ints", []int{1, -2}).
+		Errs("errs", []error{errors.New("e1"), nil}).
+		Writes("done")
+	check(t, `INF [] done // strs=[a b c], ints=[1 -2], errs=[e1 null]`)
+
+	// Allocation: items are stored in the pooled Entry.
+	al := alog.New(nil)
+	strs := []string{"a", "b", "c"}
+	ints := []int{1, 2, 3}
+	if n := testing.AllocsPerRun(100, func() {
+		al.Info(0).Strs("s", strs).Ints("i", ints).Write()
+	}); n != 0 && !raceEnabled {
+		t.Errorf("unexpected allocation: %f", n)
+	}
+}
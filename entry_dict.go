@@ -0,0 +1,53 @@
+package alog
+
+// ObjectMarshaler can be implemented by a user's type to add
+// its own fields to an Entry without using reflection.
+//   eg. func (r Req) MarshalLogObject(e *alog.Entry) {
+//           e.Str("method", r.Method).Int("status", r.Status)
+//       }
+type ObjectMarshaler interface {
+	MarshalLogObject(*Entry)
+}
+
+// Dict adds KeyValue item of a nested object. Fields added by fn
+// will be nested under the key.
+//   eg. al.Info().Dict("http", func(e *alog.Entry) {
+//           e.Str("method", "GET").Int("status", 200)
+//       }).Write()
+//   Output: {...,"http":{"method":"GET","status":200}}
+func (e *Entry) Dict(key string, fn func(*Entry)) *Entry {
+	if e != nil {
+		start := len(e.kvs)
+		if fn != nil {
+			fn(e)
+		}
+		e.endDict(key, start)
+	}
+	return e
+}
+
+// Object adds KeyValue item of a nested object using ObjectMarshaler.
+// If obj is nil, an empty object will be added.
+func (e *Entry) Object(key string, obj ObjectMarshaler) *Entry {
+	if e != nil {
+		start := len(e.kvs)
+		if obj != nil {
+			obj.MarshalLogObject(e)
+		}
+		e.endDict(key, start)
+	}
+	return e
+}
+
+// endDict moves items added since start to the sub slice, and
+// adds a KvDict item for them. As a nested Dict ends before its
+// parent, its items would have been moved already.
+func (e *Entry) endDict(key string, start int) {
+	subStart := len(e.sub)
+	e.sub = append(e.sub, e.kvs[start:]...)
+	e.kvs = append(e.kvs[:start], KeyValue{
+		Vtype: KvDict,
+		Key:   key,
+		Vkvs:  e.subFrom(subStart),
+	})
+}
@@ -0,0 +1,55 @@
+package alog_test
+
+import (
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"testing"
+)
+
+type fakeReq struct {
+	Method string
+	Status int
+}
+
+func (r *fakeReq) MarshalLogObject(e *alog.Entry) {
+	e.Str("method", r.Method).Int("status", r.Status)
+}
+
+func TestEntry_Dict(t *testing.T) {
+	reset()
+	log.Info(0).
+		Dict("http", func(e *alog.Entry) {
+			e.Str("method", "GET").
+				Dict("res", func(e *alog.Entry) {
+					e.Int("status", 200).Strs("hdr", []string{"a", "b"})
+				}).
+				Bool("ok", true)
+		}).
+		Object("req", &fakeReq{Method: "POST", Status: 201}).
+		Object("nil", nil).
+		Int("id", 1).
+		Writes("done")
+	check(t, `{"level":"info","tag":[],"message":"done","http":{"method":"GET","res":{"status":200,"hdr":["a","b"]},"ok":true},"req":{"method":"POST","status":201},"nil":{},"id":1}`)
+
+	log = log.Ext(ext.LogFmt.Text())
+	log.Info(0).
+		Dict("http", func(e *alog.Entry) {
+			e.Str("method", "GET").
+				Dict("res", func(e *alog.Entry) {
+					e.Int("status", 200)
+				})
+		}).
+		Object("nil", nil).
+		Int("id", 1).
+		Writes("done")
+	check(t, `INF [] done // http.method="GET", http.res.status=200, nil={}, id=1`)
+
+	// Allocation
+	al := alog.New(nil)
+	req := &fakeReq{Method: "GET", Status: 200}
+	if n := testing.AllocsPerRun(100, func() {
+		al.Info(0).Object("req", req).Write()
+	}); n != 0 && !raceEnabled {
+		t.Errorf("unexpected allocation: %f", n)
+	}
+}
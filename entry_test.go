@@ -1,10 +1,16 @@
 package alog_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"io/ioutil"
+	"math"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEntry(t *testing.T) {
@@ -146,3 +152,112 @@ func TestEntry_Fn(t *testing.T) {
 	log.Debug(0).Ext(fakeEntryFn(data)).Writes("added fake data2") // this shouldn't be added
 	check(t, ``)
 }
+
+func TestEntry_Types(t *testing.T) {
+	reset()
+	tm := time.Date(2021, 3, 8, 20, 33, 37, 123000000, time.UTC)
+	log.Info(0).
+		Uint("u", 12).
+		Uint64("u64", math.MaxUint64).
+		Duration("dur", 1500*time.Millisecond).
+		Time("tm", tm).
+		Time("zero", time.Time{}).
+		Time("far", time.Date(3000, 1, 1, 0, 0, 0, 5, time.UTC)).
+		Time("past", time.Date(1500, 6, 1, 0, 0, 0, 0, time.UTC)).
+		Bytes("b", []byte("a\"b")).
+		Hex("hex", []byte{0x0a, 0xff}).
+		Base64("b64", []byte("hello")).
+		Writes("done")
+	check(t, `{"level":"info","tag":[],"message":"done","u":12,"u64":18446744073709551615,"dur":"1.5s","tm":"2021-03-08T20:33:37.123Z","zero":null,"far":"3000-01-01T00:00:00.000000005Z","past":"1500-06-01T00:00:00Z","b":"a\"b","hex":"0aff","b64":"aGVsbG8="}`)
+
+	log = log.Ext(ext.LogFmt.Text())
+	log.Info(0).
+		Uint64("u64", math.MaxUint64).
+		Duration("dur", 1500*time.Millisecond).
+		Time("tm", tm).
+		Bytes("b", []byte("ab")).
+		Hex("hex", []byte{0x0a, 0xff}).
+		Base64("b64", []byte("hello")).
+		Writes("done")
+	check(t, `INF [] done // u64=18446744073709551615, dur=1.5s, tm=2021-03-08T20:33:37.123Z, b="ab", hex=0aff, b64=aGVsbG8=`)
+
+	// Allocation
+	al := alog.New(nil)
+	b := []byte("hello")
+	if n := testing.AllocsPerRun(100, func() {
+		al.Info(0).Uint64("u", 1).Duration("d", time.Second).Time("t", tm).
+			Bytes("b", b).Hex("h", b).Base64("b64", b).Write()
+	}); n != 0 && !raceEnabled {
+		t.Errorf("unexpected allocation: %f", n)
+	}
+}
+
+func TestEntry_TimeModes(t *testing.T) {
+	var out bytes.Buffer
+	al := alog.New(&out)
+	al.Location = time.FixedZone("IST", 5*3600+30*60)
+
+	for _, tc := range []struct {
+		flag   alog.Flag
+		prefix string
+		suffix string
+		size   int
+	}{
+		{alog.WithRFC3339, `{"ts":"`, `+05:30"}`, len(`{"ts":"2021-03-08T20:33:37+05:30"}`)},
+		{alog.WithRFC3339Nano, `{"ts":"`, `+05:30"}`, len(`{"ts":"2021-03-08T20:33:37.123456789+05:30"}`)},
+		{alog.WithRFC3339 | alog.WithUTC, `{"ts":"`, `Z"}`, len(`{"ts":"2021-03-08T20:33:37Z"}`)},
+		{alog.WithUnixTimeUs, `{"ts":1`, `}`, len(`{"ts":1615235617123456}`)},
+		{alog.WithUnixTimeNs, `{"ts":1`, `}`, len(`{"ts":1615235617123456789}`)},
+	} {
+		out.Reset()
+		al.Flag = tc.flag
+		al.Info().Write()
+		s := strings.TrimSpace(out.String())
+		if !strings.HasPrefix(s, tc.prefix) || !strings.HasSuffix(s, tc.suffix) || len(s) != tc.size {
+			t.Errorf("unexpected output for flag %d: %s", tc.flag, s)
+		}
+	}
+
+	al = alog.New(ioutil.Discard)
+	al.Flag = alog.WithRFC3339Nano
+	al.Location = time.UTC
+	if n := testing.AllocsPerRun(100, func() {
+		al.Info().Write()
+	}); n != 0 && !raceEnabled {
+		t.Errorf("unexpected allocs: %v", n)
+	}
+}
+
+func TestEntry_Clock(t *testing.T) {
+	now := time.Date(2021, 3, 8, 20, 33, 37, 123456789, time.UTC)
+	var out, out2 bytes.Buffer
+	al := alog.New(&out)
+	al.Flag = alog.WithRFC3339Nano
+	al.Clock = func() time.Time { return now }
+	sink := alog.NewSink(&out2, ext.NewFormatterLogfmt(""))
+	sink.Flag = alog.WithRFC3339Nano | alog.WithLevel
+	al = al.AddSink(sink)
+
+	al.Info().Writes("clock")
+	exp := `{"ts":"2021-03-08T20:33:37.123456789Z","message":"clock"}` + "\n"
+	if out.String() != exp {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+	// Sinks will have the same time.
+	if exp2 := "ts=2021-03-08T20:33:37.123456789Z level=info msg=clock\n"; out2.String() != exp2 {
+		t.Errorf("unexpected sink output: %s", out2.String())
+	}
+
+	// At overrides the clock.
+	out.Reset()
+	al.Info().At(now.Add(-time.Hour)).Writes("at")
+	if exp = `{"ts":"2021-03-08T19:33:37.123456789Z","message":"at"}` + "\n"; out.String() != exp {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+	// At is not kept for the next entry.
+	out.Reset()
+	al.Info().Writes("clock")
+	if exp = `{"ts":"2021-03-08T20:33:37.123456789Z","message":"clock"}` + "\n"; out.String() != exp {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
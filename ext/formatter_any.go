@@ -0,0 +1,32 @@
+package ext
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// appendAny will append a value of alog.KvAny for text formatters.
+// It will use, in order, error, fmt.Stringer, encoding.TextMarshaler
+// and encoding/json. A nil pointer will be null without calling its methods.
+func appendAny(dst []byte, val interface{}) []byte {
+	if rv := reflect.ValueOf(val); val == nil || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return append(dst, "null"...)
+	}
+	switch v := val.(type) {
+	case error:
+		return append(dst, v.Error()...)
+	case fmt.Stringer:
+		return append(dst, v.String()...)
+	case encoding.TextMarshaler:
+		if b, err := v.MarshalText(); err == nil {
+			return append(dst, b...)
+		}
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return append(dst, "!ERROR: "+err.Error()...)
+	}
+	return append(dst, b...)
+}
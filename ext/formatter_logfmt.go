@@ -0,0 +1,259 @@
+package ext
+
+import (
+	"github.com/gonyyi/alog"
+	"io"
+	"strconv"
+	"time"
+)
+
+// NewFormatterLogfmt returns a formatter for logfmt such as
+// `ts=2021-03-08T20:33:37.123+09:00 level=info tag=DB,Disk msg="hello world" status=200`.
+// layout is a time layout for `ts` such as time.RFC3339; if empty,
+// time.RFC3339Nano will be used. With WithUnixTime flags such as WithUnixTimeMs,
+// `ts` will be unix time instead. Keys and values are quoted and escaped when
+// needed, and nested objects are flattened with dot notation.
+func NewFormatterLogfmt(layout string) *fmtLogfmt {
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	return &fmtLogfmt{layout: layout}
+}
+
+type fmtLogfmt struct {
+	out       alog.Writer
+	format    alog.Flag
+	tagBucket *alog.TagBucket
+	layout    string
+}
+
+func (f *fmtLogfmt) Init(w alog.Writer, formatFlag alog.Flag, tagBucket *alog.TagBucket) {
+	f.out = w
+	if w == nil {
+		f.out = alog.Discard{}
+	}
+
+	f.format = formatFlag
+	f.tagBucket = tagBucket
+}
+
+func (f *fmtLogfmt) Write(dst []byte, level alog.Level, tag alog.Tag) (int, error) {
+	return f.out.WriteLt(dst, level, tag)
+}
+
+func (f *fmtLogfmt) Close() error {
+	if c, ok := f.out.(io.Closer); ok && c != nil {
+		return c.Close()
+	}
+	return nil
+}
+
+func (fmtLogfmt) Begin(dst []byte) []byte {
+	return dst
+}
+
+func (f *fmtLogfmt) AddTime(dst []byte, t time.Time) []byte {
+	if (alog.WithDate|alog.WithDay|alog.WithTime|alog.WithTimeMs|alog.WithRFC3339|alog.WithRFC3339Nano|
+		alog.WithUnixTime|alog.WithUnixTimeMs|alog.WithUnixTimeUs|alog.WithUnixTimeNs)&f.format == 0 {
+		return dst
+	}
+	dst = append(dst, "ts="...)
+	switch {
+	case alog.WithUnixTimeNs&f.format != 0:
+		dst = strconv.AppendInt(dst, t.UnixNano(), 10)
+	case alog.WithUnixTimeUs&f.format != 0:
+		dst = strconv.AppendInt(dst, t.UnixNano()/1e3, 10)
+	case alog.WithUnixTimeMs&f.format != 0:
+		dst = strconv.AppendInt(dst, t.UnixNano()/1e6, 10)
+	case alog.WithUnixTime&f.format != 0:
+		dst = strconv.AppendInt(dst, t.Unix(), 10)
+	default:
+		if alog.WithUTC&f.format != 0 {
+			t = t.UTC()
+		}
+		start := len(dst)
+		dst = f.quoteFrom(t.AppendFormat(dst, f.layout), start)
+	}
+	return append(dst, ' ')
+}
+
+func (f *fmtLogfmt) AddLevel(dst []byte, level alog.Level) []byte {
+	if alog.WithLevel&f.format != 0 {
+		return append(append(append(dst, "level="...), level.Name()...), ' ')
+	}
+	return dst
+}
+
+// AddTag adds names of tags as a comma-joined value such as `tag=DB,Disk`.
+// Entries without a tag will not have `tag`.
+func (f *fmtLogfmt) AddTag(dst []byte, tag alog.Tag) []byte {
+	if alog.WithTag&f.format != 0 && f.tagBucket != nil && tag != 0 {
+		dst = append(dst, "tag="...)
+		start := len(dst)
+		return append(f.quoteFrom(f.tagBucket.AppendTag(dst, tag), start), ' ')
+	}
+	return dst
+}
+
+func (f *fmtLogfmt) AddMsg(dst []byte, s string) []byte {
+	dst = append(dst, "msg="...)
+	start := len(dst)
+	return append(f.quoteFrom(append(dst, s...), start), ' ')
+}
+
+func (f *fmtLogfmt) AddKVs(dst []byte, kvs []alog.KeyValue) []byte {
+	for i := 0; i < len(kvs); i++ {
+		dst = f.addKV(dst, nil, &kvs[i])
+	}
+	return dst
+}
+
+func (fmtLogfmt) End(dst []byte) []byte {
+	if len(dst) > 0 && dst[len(dst)-1] == ' ' {
+		dst[len(dst)-1] = '\n'
+		return dst
+	}
+	return append(dst, '\n')
+}
+
+// addKV will add a key value item. Items of KvDict will be
+// flattened with dot notation such as `http.method=GET`.
+func (f *fmtLogfmt) addKV(dst []byte, parents []string, kv *alog.KeyValue) []byte {
+	if kv.Vtype == alog.KvDict {
+		parents = append(parents, kv.Key)
+		for i := 0; i < len(kv.Vkvs); i++ {
+			dst = f.addKV(dst, parents, &kv.Vkvs[i])
+		}
+		return dst
+	}
+	for i := 0; i < len(parents); i++ {
+		dst = append(f.addKey(dst, parents[i]), '.')
+	}
+	dst = append(f.addKey(dst, kv.Key), '=')
+
+	start := len(dst)
+	switch kv.Vtype {
+	case alog.KvString:
+		dst = append(dst, kv.Vstr...)
+	case alog.KvBool:
+		dst = strconv.AppendBool(dst, kv.Vbool)
+	case alog.KvError:
+		if kv.Verr == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = append(dst, kv.Verr.Error()...)
+		}
+	case alog.KvInt:
+		dst = strconv.AppendInt(dst, kv.Vint, 10)
+	case alog.KvFloat64:
+		dst = strconv.AppendFloat(dst, kv.Vf64, 'f', -1, 64)
+	case alog.KvUint:
+		dst = strconv.AppendUint(dst, kv.Uint(), 10)
+	case alog.KvDuration:
+		dst = alog.AppendDuration(dst, kv.Duration())
+	case alog.KvTime:
+		if kv.Vany == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = kv.Time().AppendFormat(dst, f.layout)
+		}
+	case alog.KvBytes:
+		dst = append(dst, kv.Vbyte...)
+	case alog.KvHex:
+		dst = alog.AppendHex(dst, kv.Vbyte)
+	case alog.KvBase64:
+		dst = alog.AppendBase64(dst, kv.Vbyte)
+	case alog.KvStrs, alog.KvInts, alog.KvFloats, alog.KvBools, alog.KvErrs:
+		dst = fmtTxt{}.addValArr(dst, kv.Vkvs)
+	case alog.KvStack:
+		// frames are separated by a newline which will be escaped.
+		for i := 0; i < len(kv.Vkvs); i++ {
+			if i > 0 {
+				dst = append(dst, '\n')
+			}
+			dst = append(append(append(dst, kv.Vkvs[i].Key...), ' '), kv.Vkvs[i].Vstr...)
+			dst = strconv.AppendInt(append(dst, ':'), kv.Vkvs[i].Vint, 10)
+		}
+	case alog.KvAny:
+		dst = appendAny(dst, kv.Vany)
+	default:
+		dst = append(dst, "null"...)
+	}
+	return append(f.quoteFrom(dst, start), ' ')
+}
+
+// addKey adds a key. As logfmt keys can't have spaces, `=`, `"`
+// or control characters, those will be replaced with `_`.
+func (fmtLogfmt) addKey(dst []byte, key string) []byte {
+	if key == "" {
+		return append(dst, '_')
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			dst = append(dst, '_')
+		} else {
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// quoteFrom quotes and escapes dst[start:] in place when it's empty or has
+// spaces, `=`, `"`, `\` or control characters. Otherwise, dst is returned as is.
+func (fmtLogfmt) quoteFrom(dst []byte, start int) []byte {
+	quote := len(dst) == start
+	n := 0 // n is the number of bytes added by escaping
+	for i := start; i < len(dst); i++ {
+		switch c := dst[i]; {
+		case c == '"' || c == '\\' || c == '\n' || c == '\r' || c == '\t':
+			quote = true
+			n++
+		case c < ' ' || c == 0x7f:
+			quote = true
+			n += 5 // \u00XX
+		case c == ' ' || c == '=':
+			quote = true
+		}
+	}
+	if !quote {
+		return dst
+	}
+
+	// Move from the back so it can be done in place.
+	end := len(dst)
+	for i := 0; i < n+2; i++ {
+		dst = append(dst, 0)
+	}
+	j := len(dst) - 1
+	dst[j] = '"'
+	j--
+	for i := end - 1; i >= start; i-- {
+		switch c := dst[i]; c {
+		case '"', '\\':
+			dst[j], dst[j-1] = c, '\\'
+			j -= 2
+		case '\n':
+			dst[j], dst[j-1] = 'n', '\\'
+			j -= 2
+		case '\r':
+			dst[j], dst[j-1] = 'r', '\\'
+			j -= 2
+		case '\t':
+			dst[j], dst[j-1] = 't', '\\'
+			j -= 2
+		default:
+			if c < ' ' || c == 0x7f {
+				dst[j], dst[j-1] = hexChars[c&0xf], hexChars[c>>4]
+				dst[j-2], dst[j-3], dst[j-4], dst[j-5] = '0', '0', 'u', '\\'
+				j -= 6
+			} else {
+				dst[j] = c
+				j--
+			}
+		}
+	}
+	dst[j] = '"'
+	return dst
+}
+
+const hexChars = "0123456789abcdef"
@@ -0,0 +1,54 @@
+package ext_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"testing"
+	"time"
+)
+
+func TestFormatterLogfmt(t *testing.T) {
+	var out bytes.Buffer
+	al := alog.New(&out)
+	tagDB := al.NewTag("DB")
+	tagDisk := al.NewTag("Disk")
+	al.Flag = alog.WithLevel | alog.WithTag
+	al = al.Ext(ext.LogFmt.Logfmt())
+
+	for _, tc := range []struct {
+		fn  func()
+		exp string
+	}{
+		{func() { al.Info(tagDB, tagDisk).Int("status", 200).Writes("hello world") },
+			`level=info tag=DB,Disk msg="hello world" status=200`},
+		{func() { al.Warn().Str("a b", `say "hi"`).Str("empty", "").Str("eq", "x=y").Writes("ok") },
+			`level=warn msg=ok a_b="say \"hi\"" empty="" eq="x=y"`},
+		{func() { al.Error().Err(errors.New("line1\nline2\x01")).Write() },
+			`level=error error="line1\nline2\u0001"`},
+		{func() {
+			al.Info().Dict("http", func(e *alog.Entry) {
+				e.Str("method", "GET").Int("status", 200)
+			}).Ints("ids", []int{1, 2}).Duration("took", 1500*time.Millisecond).Write()
+		}, `level=info http.method=GET http.status=200 ids="[1 2]" took=1.5s`},
+	} {
+		out.Reset()
+		tc.fn()
+		if exp := tc.exp + "\n"; out.String() != exp {
+			t.Errorf("\nexpected: %q\n     got: %q", exp, out.String())
+		}
+	}
+}
+
+func TestFormatterLogfmt_Time(t *testing.T) {
+	var out bytes.Buffer
+	al := alog.New(&out)
+	al.Flag = alog.WithTime | alog.WithUTC
+	al = al.SetFormatter(ext.NewFormatterLogfmt("2006-01-02 15:04"))
+	al.Info().Write()
+	// quoted as the layout has a space.
+	if b := out.Bytes(); len(b) != len(`ts="2021-03-08 20:33"`)+1 || !bytes.HasPrefix(b, []byte(`ts="`)) {
+		t.Errorf("unexpected output: %q", b)
+	}
+}
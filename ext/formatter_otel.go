@@ -0,0 +1,263 @@
+package ext
+
+import (
+	"bytes"
+	"github.com/gonyyi/alog"
+	"io"
+	"strconv"
+	"time"
+)
+
+// otelAttrs is the beginning of attributes. Tags are added right after it
+// by AddTag, and AddMsg will add the body before it.
+const otelAttrs = `"attributes":[`
+
+var otelAttrsBytes = []byte(otelAttrs)
+
+// NewFormatterOTel returns a formatter writing each entry as an OpenTelemetry
+// LogRecord in OTLP JSON such as
+// `{"timeUnixNano":"1615235617000000000","severityNumber":9,"severityText":"INFO",
+// "body":{"stringValue":"hello"},"attributes":[{"key":"status","value":{"intValue":"200"}}]}`.
+// Tags are added as the `alog.tag` attribute of a string array. String or
+// hex values of `trace_id` and `span_id` keys become `traceId` and `spanId`.
+// Time is always added regardless of the flag. To send records to a collector,
+// use it with NewOTLPWriter.
+func NewFormatterOTel() *fmtOTel {
+	return &fmtOTel{
+		TraceKey: "trace_id",
+		SpanKey:  "span_id",
+	}
+}
+
+type fmtOTel struct {
+	out       alog.Writer
+	format    alog.Flag
+	tagBucket *alog.TagBucket
+
+	// TraceKey and SpanKey are keys for trace and span IDs.
+	TraceKey string
+	SpanKey  string
+}
+
+func (f *fmtOTel) Init(w alog.Writer, formatFlag alog.Flag, tagBucket *alog.TagBucket) {
+	f.out = w
+	if w == nil {
+		f.out = alog.Discard{}
+	}
+
+	f.format = formatFlag
+	f.tagBucket = tagBucket
+}
+
+func (f *fmtOTel) Write(dst []byte, level alog.Level, tag alog.Tag) (int, error) {
+	return f.out.WriteLt(dst, level, tag)
+}
+
+func (f *fmtOTel) Close() error {
+	if c, ok := f.out.(io.Closer); ok && c != nil {
+		return c.Close()
+	}
+	return nil
+}
+
+func (fmtOTel) Begin(dst []byte) []byte {
+	return append(dst, '{')
+}
+
+func (fmtOTel) AddTime(dst []byte, t time.Time) []byte {
+	dst = append(dst, `"timeUnixNano":"`...)
+	return append(strconv.AppendInt(dst, t.UnixNano(), 10), '"', ',')
+}
+
+// OTelSeverity returns the severity number and text of OpenTelemetry for the level.
+func OTelSeverity(level alog.Level) (int, string) {
+	switch level {
+	case alog.TraceLevel:
+		return 1, "TRACE"
+	case alog.DebugLevel:
+		return 5, "DEBUG"
+	case alog.InfoLevel:
+		return 9, "INFO"
+	case alog.WarnLevel:
+		return 13, "WARN"
+	case alog.ErrorLevel:
+		return 17, "ERROR"
+	case alog.FatalLevel:
+		return 21, "FATAL"
+	default:
+		return 0, ""
+	}
+}
+
+func (fmtOTel) AddLevel(dst []byte, level alog.Level) []byte {
+	n, s := OTelSeverity(level)
+	dst = strconv.AppendInt(append(dst, `"severityNumber":`...), int64(n), 10)
+	return append(append(append(dst, `,"severityText":"`...), s...), '"', ',')
+}
+
+// AddTag begins attributes with the tag attribute. Attributes
+// will be closed by AddKVs.
+func (f *fmtOTel) AddTag(dst []byte, tag alog.Tag) []byte {
+	dst = append(dst, otelAttrs...)
+	if f.tagBucket == nil || tag == 0 {
+		return dst
+	}
+	dst = append(dst, `{"key":"alog.tag","value":{"arrayValue":{"values":[`...)
+	for i := 0; i < 64; i++ {
+		if t := alog.Tag(1) << i; tag&t != 0 {
+			dst = alog.AppendJSONString(append(dst, `{"stringValue":`...), f.tagBucket.Name(t), true)
+			dst = append(dst, '}', ',')
+		}
+	}
+	dst[len(dst)-1] = ']'
+	return append(dst, "}}},"...)
+}
+
+// AddMsg adds the body before attributes.
+func (fmtOTel) AddMsg(dst []byte, s string) []byte {
+	i := bytes.LastIndex(dst, otelAttrsBytes)
+	if i < 0 {
+		i = len(dst)
+	}
+	n := len(dst)
+	dst = append(dst, `"body":{"stringValue":`...)
+	dst = append(alog.AppendJSONString(dst, s, true), '}', ',')
+	// Move the body before attributes.
+	body := len(dst) - n
+	dst = append(dst, dst[i:n]...)
+	copy(dst[i:], dst[n:n+body])
+	copy(dst[i+body:], dst[len(dst)-(n-i):])
+	return dst[:n+body]
+}
+
+// AddKVs adds kvs as attributes and closes them. Trace and span IDs
+// will be added after attributes.
+func (f *fmtOTel) AddKVs(dst []byte, kvs []alog.KeyValue) []byte {
+	trace, span := -1, -1
+	for i := 0; i < len(kvs); i++ {
+		if kvs[i].Vtype == alog.KvString || kvs[i].Vtype == alog.KvHex {
+			if kvs[i].Key == f.TraceKey && trace < 0 {
+				trace = i
+				continue
+			}
+			if kvs[i].Key == f.SpanKey && span < 0 {
+				span = i
+				continue
+			}
+		}
+		dst = f.addAttr(dst, &kvs[i])
+		dst = append(dst, ',')
+	}
+	if dst[len(dst)-1] == ',' {
+		dst = dst[:len(dst)-1]
+	}
+	dst = append(dst, ']', ',')
+
+	if trace >= 0 {
+		dst = f.addID(append(dst, `"traceId":`...), &kvs[trace])
+	}
+	if span >= 0 {
+		dst = f.addID(append(dst, `"spanId":`...), &kvs[span])
+	}
+	return dst
+}
+
+func (fmtOTel) End(dst []byte) []byte {
+	dst[len(dst)-1] = '}'
+	return append(dst, '\n')
+}
+
+// addID adds a trace or span ID as a hex string.
+func (fmtOTel) addID(dst []byte, kv *alog.KeyValue) []byte {
+	if kv.Vtype == alog.KvHex {
+		return append(alog.AppendHex(append(dst, '"'), kv.Vbyte), '"', ',')
+	}
+	return append(alog.AppendJSONString(dst, kv.Vstr, true), ',')
+}
+
+// addAttr adds an attribute of a KeyValue.
+func (f *fmtOTel) addAttr(dst []byte, kv *alog.KeyValue) []byte {
+	dst = alog.AppendJSONString(append(dst, `{"key":`...), kv.Key, true)
+	dst = f.addValue(append(dst, `,"value":`...), kv)
+	return append(dst, '}')
+}
+
+// addValue adds an AnyValue of OTLP JSON.
+func (f *fmtOTel) addValue(dst []byte, kv *alog.KeyValue) []byte {
+	switch kv.Vtype {
+	case alog.KvString:
+		return append(alog.AppendJSONString(append(dst, `{"stringValue":`...), kv.Vstr, true), '}')
+	case alog.KvBool:
+		return append(strconv.AppendBool(append(dst, `{"boolValue":`...), kv.Vbool), '}')
+	case alog.KvInt:
+		return append(strconv.AppendInt(append(dst, `{"intValue":"`...), kv.Vint, 10), '"', '}')
+	case alog.KvUint:
+		// Too large for intValue, it will be a string.
+		if kv.Vint < 0 {
+			return append(strconv.AppendUint(append(dst, `{"stringValue":"`...), kv.Uint(), 10), '"', '}')
+		}
+		return append(strconv.AppendInt(append(dst, `{"intValue":"`...), kv.Vint, 10), '"', '}')
+	case alog.KvFloat64:
+		return append(strconv.AppendFloat(append(dst, `{"doubleValue":`...), kv.Vf64, 'f', -1, 64), '}')
+	case alog.KvError:
+		if kv.Verr == nil {
+			return append(dst, "{}"...)
+		}
+		return append(alog.AppendJSONString(append(dst, `{"stringValue":`...), kv.Verr.Error(), true), '}')
+	case alog.KvDuration:
+		return append(alog.AppendDuration(append(dst, `{"stringValue":"`...), kv.Duration()), '"', '}')
+	case alog.KvTime:
+		if kv.Vany == nil {
+			return append(dst, "{}"...)
+		}
+		return append(kv.Time().AppendFormat(append(dst, `{"stringValue":"`...), time.RFC3339Nano), '"', '}')
+	case alog.KvBytes:
+		return append(alog.AppendJSONBytes(append(dst, `{"stringValue":`...), kv.Vbyte, true), '}')
+	case alog.KvHex:
+		return append(alog.AppendHex(append(dst, `{"stringValue":"`...), kv.Vbyte), '"', '}')
+	case alog.KvBase64:
+		return append(alog.AppendBase64(append(dst, `{"bytesValue":"`...), kv.Vbyte), '"', '}')
+	case alog.KvStrs, alog.KvInts, alog.KvFloats, alog.KvBools, alog.KvErrs:
+		dst = append(dst, `{"arrayValue":{"values":[`...)
+		for i := 0; i < len(kv.Vkvs); i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = f.addValue(dst, &kv.Vkvs[i])
+		}
+		return append(dst, "]}}"...)
+	case alog.KvDict:
+		dst = append(dst, `{"kvlistValue":{"values":[`...)
+		for i := 0; i < len(kv.Vkvs); i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = f.addAttr(dst, &kv.Vkvs[i])
+		}
+		return append(dst, "]}}"...)
+	case alog.KvStack:
+		// frames are separated by a newline.
+		start := len(dst)
+		for i := 0; i < len(kv.Vkvs); i++ {
+			if i > 0 {
+				dst = append(dst, '\n')
+			}
+			dst = append(append(append(dst, kv.Vkvs[i].Key...), ' '), kv.Vkvs[i].Vstr...)
+			dst = strconv.AppendInt(append(dst, ':'), kv.Vkvs[i].Vint, 10)
+		}
+		return f.stringFrom(dst, start)
+	case alog.KvAny:
+		return f.stringFrom(appendAny(dst, kv.Vany), len(dst))
+	default:
+		return append(dst, "{}"...)
+	}
+}
+
+// stringFrom replaces dst[start:] with a stringValue of it.
+func (fmtOTel) stringFrom(dst []byte, start int) []byte {
+	end := len(dst)
+	dst = append(dst, `{"stringValue":`...)
+	dst = append(alog.AppendJSONBytes(dst, dst[start:end], true), '}')
+	n := copy(dst[start:], dst[end:])
+	return dst[:start+n]
+}
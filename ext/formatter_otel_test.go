@@ -0,0 +1,66 @@
+package ext_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"strings"
+	"testing"
+)
+
+func TestFormatterOTel(t *testing.T) {
+	var out bytes.Buffer
+	al := alog.New(&out)
+	tagDB := al.NewTag("DB")
+	tagDisk := al.NewTag("Disk")
+	al = al.Ext(ext.LogFmt.OTel())
+
+	al.Warn(tagDB|tagDisk).
+		Str("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736").
+		Str("span_id", "00f067aa0ba902b7").
+		Int("status", 500).
+		Strs("ids", []string{"a", "b"}).
+		Dict("http", func(e *alog.Entry) { e.Str("method", "GET") }).
+		Writes(`say "hi"`)
+
+	var rec struct {
+		TimeUnixNano   string
+		SeverityNumber int
+		SeverityText   string
+		Body           struct{ StringValue string }
+		Attributes     []struct {
+			Key   string
+			Value map[string]interface{}
+		}
+		TraceId string
+		SpanId  string
+	}
+	if err := json.Unmarshal(out.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid json: %v: %s", err, out.String())
+	}
+	if rec.TimeUnixNano == "" || rec.SeverityNumber != 13 || rec.SeverityText != "WARN" ||
+		rec.Body.StringValue != `say "hi"` ||
+		rec.TraceId != "4bf92f3577b34da6a3ce929d0e0e4736" || rec.SpanId != "00f067aa0ba902b7" {
+		t.Errorf("unexpected record: %s", out.String())
+	}
+	var keys []string
+	for _, a := range rec.Attributes {
+		keys = append(keys, a.Key)
+	}
+	if strings.Join(keys, ",") != "alog.tag,status,ids,http" {
+		t.Errorf("unexpected attributes: %v", keys)
+	}
+	if !strings.Contains(out.String(), `{"key":"alog.tag","value":{"arrayValue":{"values":[{"stringValue":"DB"},{"stringValue":"Disk"}]}}}`) ||
+		!strings.Contains(out.String(), `{"key":"status","value":{"intValue":"500"}}`) ||
+		!strings.Contains(out.String(), `{"key":"http","value":{"kvlistValue":{"values":[{"key":"method","value":{"stringValue":"GET"}}]}}}`) {
+		t.Errorf("unexpected attributes: %s", out.String())
+	}
+
+	// Without a message and fields
+	out.Reset()
+	al.Info().Write()
+	if err := json.Unmarshal(out.Bytes(), &rec); err != nil || !strings.HasSuffix(out.String(), `"attributes":[]}`+"\n") {
+		t.Errorf("unexpected record: %v: %s", err, out.String())
+	}
+}
@@ -47,13 +47,17 @@ func (fmtTxt) Begin(dst []byte) []byte {
 	return dst
 }
 
-func (f *fmtTxt) AddTime(dst []byte) []byte {
-	if (alog.WithUnixTime|alog.WithDate|alog.WithTime|alog.WithTimeMs)&f.format != 0 {
+func (f *fmtTxt) AddTime(dst []byte, t time.Time) []byte {
+	if (alog.WithUnixTime|alog.WithDate|alog.WithTime|alog.WithTimeMs|alog.WithRFC3339|alog.WithRFC3339Nano)&f.format != 0 {
 		switch {
+		case alog.WithRFC3339Nano&f.format != 0:
+			return append(alog.AppendRFC3339(dst, t, true), ' ')
+		case alog.WithRFC3339&f.format != 0:
+			return append(alog.AppendRFC3339(dst, t, false), ' ')
 		case alog.WithTimeMs&f.format != 0:
-			return append(append(dst, time.Now().Format("2006/01/02 15:04:05.000")...), ' ')
+			return append(t.AppendFormat(dst, "2006/01/02 15:04:05.000"), ' ')
 		default:
-			return append(append(dst, time.Now().Format("2006/01/02 15:04:05")...), ' ')
+			return append(t.AppendFormat(dst, "2006/01/02 15:04:05"), ' ')
 		}
 	}
 	return dst
@@ -78,30 +82,21 @@ func (fmtTxt) AddMsg(dst []byte, s string) []byte {
 }
 
 func (f *fmtTxt) AddKVs(dst []byte, kvs []alog.KeyValue) []byte {
-	if len(kvs) > 0 {
-		dst = append(dst, `// `...)
-	}
-
+	n, stacks := 0, 0
 	for i := 0; i < len(kvs); i++ {
-		dst = append(append(dst, kvs[i].Key...), '=')
-		switch kvs[i].Vtype {
-		case alog.KvString:
-			dst = f.addValStringUnsafe(dst, kvs[i].Vstr)
-		case alog.KvBool:
-			dst = f.addValBool(dst, kvs[i].Vbool)
-		case alog.KvError:
-			if kvs[i].Verr == nil {
-				dst = append(dst, "null, "...)
-			} else {
-				dst = f.addValString(dst, kvs[i].Verr.Error())
-			}
-		case alog.KvInt:
-			dst = f.addValInt(dst, kvs[i].Vint)
-		case alog.KvFloat64:
-			dst = f.addValFloat(dst, kvs[i].Vf64)
-		default:
-			dst = append(dst, `null, `...)
+		// stack will be shown as an indented block after the line.
+		if kvs[i].Vtype == alog.KvStack {
+			stacks++
+			continue
+		}
+		if n == 0 {
+			dst = append(dst, `// `...)
 		}
+		n++
+		dst = f.addKV(dst, nil, &kvs[i])
+	}
+	if stacks > 0 {
+		dst = f.addStacks(dst, kvs)
 	}
 	return dst
 }
@@ -117,6 +112,114 @@ func (fmtTxt) End(dst []byte) []byte {
 	return dst
 }
 
+// addKV will add a key value item. Items of KvDict will be
+// flattened with dot notation such as `http.method="GET"`.
+func (f *fmtTxt) addKV(dst []byte, parents []string, kv *alog.KeyValue) []byte {
+	if kv.Vtype == alog.KvDict && len(kv.Vkvs) > 0 {
+		parents = append(parents, kv.Key)
+		for i := 0; i < len(kv.Vkvs); i++ {
+			dst = f.addKV(dst, parents, &kv.Vkvs[i])
+		}
+		return dst
+	}
+	for i := 0; i < len(parents); i++ {
+		dst = append(append(dst, parents[i]...), '.')
+	}
+	dst = append(append(dst, kv.Key...), '=')
+	switch kv.Vtype {
+	case alog.KvString:
+		dst = f.addValStringUnsafe(dst, kv.Vstr)
+	case alog.KvBool:
+		dst = f.addValBool(dst, kv.Vbool)
+	case alog.KvError:
+		if kv.Verr == nil {
+			dst = append(dst, "null, "...)
+		} else {
+			dst = f.addValString(dst, kv.Verr.Error())
+		}
+	case alog.KvInt:
+		dst = f.addValInt(dst, kv.Vint)
+	case alog.KvFloat64:
+		dst = f.addValFloat(dst, kv.Vf64)
+	case alog.KvUint:
+		dst = append(strconv.AppendUint(dst, kv.Uint(), 10), ',', ' ')
+	case alog.KvDuration:
+		dst = append(alog.AppendDuration(dst, kv.Duration()), ',', ' ')
+	case alog.KvTime:
+		if kv.Vany == nil {
+			dst = append(dst, "null, "...)
+		} else {
+			dst = append(kv.Time().AppendFormat(dst, time.RFC3339Nano), ',', ' ')
+		}
+	case alog.KvBytes:
+		// escaped, so control characters won't reach the terminal.
+		dst = append(alog.AppendJSONBytes(dst, kv.Vbyte, true), ',', ' ')
+	case alog.KvHex:
+		dst = append(alog.AppendHex(dst, kv.Vbyte), ',', ' ')
+	case alog.KvBase64:
+		dst = append(alog.AppendBase64(dst, kv.Vbyte), ',', ' ')
+	case alog.KvStrs, alog.KvInts, alog.KvFloats, alog.KvBools, alog.KvErrs:
+		dst = append(f.addValArr(dst, kv.Vkvs), ',', ' ')
+	case alog.KvDict:
+		dst = append(dst, "{}, "...)
+	case alog.KvAny:
+		dst = append(appendAny(dst, kv.Vany), ',', ' ')
+	default:
+		dst = append(dst, `null, `...)
+	}
+	return dst
+}
+
+// addStacks will add stack frames as an indented block
+// after the line. End will add the final newline.
+func (fmtTxt) addStacks(dst []byte, kvs []alog.KeyValue) []byte {
+	if len(dst) > 1 && dst[len(dst)-2] == ',' {
+		dst = dst[:len(dst)-2]
+	} else if len(dst) > 0 && dst[len(dst)-1] == ' ' {
+		dst = dst[:len(dst)-1]
+	}
+	for i := 0; i < len(kvs); i++ {
+		if kvs[i].Vtype != alog.KvStack {
+			continue
+		}
+		for j := 0; j < len(kvs[i].Vkvs); j++ {
+			fr := kvs[i].Vkvs[j]
+			dst = append(append(dst, "\n\t"...), fr.Key...)
+			dst = strconv.AppendInt(append(append(append(dst, "\n\t\t"...), fr.Vstr...), ':'), fr.Vint, 10)
+		}
+	}
+	return dst
+}
+
+// addValArr will add array items as `[a b c]`.
+func (fmtTxt) addValArr(dst []byte, items []alog.KeyValue) []byte {
+	dst = append(dst, '[')
+	for i := 0; i < len(items); i++ {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		switch items[i].Vtype {
+		case alog.KvString:
+			dst = append(dst, items[i].Vstr...)
+		case alog.KvBool:
+			dst = strconv.AppendBool(dst, items[i].Vbool)
+		case alog.KvInt:
+			dst = strconv.AppendInt(dst, items[i].Vint, 10)
+		case alog.KvFloat64:
+			dst = strconv.AppendFloat(dst, items[i].Vf64, 'f', -1, 64)
+		case alog.KvError:
+			if items[i].Verr == nil {
+				dst = append(dst, "null"...)
+			} else {
+				dst = append(dst, items[i].Verr.Error()...)
+			}
+		default:
+			dst = append(dst, "null"...)
+		}
+	}
+	return append(dst, ']')
+}
+
 func (fmtTxt) addKeyUnsafe(dst []byte, s string) []byte {
 	return append(append(dst, s...), '=')
 }
@@ -0,0 +1,50 @@
+package ext_test
+
+import (
+	"bytes"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatterTerminal_Bytes(t *testing.T) {
+	var out bytes.Buffer
+	for _, f := range []alog.Formatter{ext.NewFormatterTerminal(), ext.NewFormatterTerminalColor()} {
+		out.Reset()
+		al := alog.New(&out)
+		al.Flag = alog.WithLevel
+		al = al.SetFormatter(f)
+		al.Info().Bytes("b", []byte("a\x1b[31mb\n")).Write()
+		if s := out.String(); strings.Contains(s, "\x1b[31mb") || !strings.Contains(s, `"a\u001b[31mb\n"`) {
+			t.Errorf("unexpected output: %q", s)
+		}
+	}
+}
+
+func TestFormatterTerminal_Time(t *testing.T) {
+	var out bytes.Buffer
+	at := time.Date(2021, 3, 8, 20, 33, 37, 0, time.UTC)
+	kst := time.FixedZone("KST", 9*3600)
+	for _, tc := range []struct {
+		flag alog.Flag
+		exp  string
+	}{
+		{alog.WithRFC3339 | alog.WithUTC, "2021-03-08T20:33:37Z INF "},
+		{alog.WithRFC3339, "2021-03-09T05:33:37+09:00 INF "},
+		{alog.WithRFC3339Nano, "2021-03-09T05:33:37.000000000+09:00 INF "},
+		{alog.WithDate | alog.WithTime, "2021/03/09 05:33:37 INF "},
+	} {
+		out.Reset()
+		al := alog.New(&out)
+		al.Flag = tc.flag | alog.WithLevel
+		al.Location = kst
+		al.Clock = func() time.Time { return at }
+		al = al.SetFormatter(ext.NewFormatterTerminal())
+		al.Info().Writes("hi")
+		if s := out.String(); !strings.HasPrefix(s, tc.exp) {
+			t.Errorf("unexpected output for %d // exp=<%s>, act=<%s>", tc.flag, tc.exp, s)
+		}
+	}
+}
@@ -26,3 +26,17 @@ func (logFormatter) TextColor() alog.LoggerFn {
 		return l
 	}
 }
+
+func (logFormatter) Logfmt() alog.LoggerFn {
+	return func(l alog.Logger) alog.Logger {
+		l = l.SetFormatter(NewFormatterLogfmt(""))
+		return l
+	}
+}
+
+func (logFormatter) OTel() alog.LoggerFn {
+	return func(l alog.Logger) alog.Logger {
+		l = l.SetFormatter(NewFormatterOTel())
+		return l
+	}
+}
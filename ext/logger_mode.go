@@ -9,10 +9,21 @@ var LogMode logMode
 
 type logMode struct{}
 
-func (logMode) Prod(filename string) alog.LoggerFn {
+// Prod writes JSON logs to a buffered file. If a RotateConfig is given,
+// a rotating file writer will be used instead. See NewRotateWriter.
+func (logMode) Prod(filename string, rotate ...RotateConfig) alog.LoggerFn {
 	return func(l alog.Logger) alog.Logger {
 		l.Control.Level = alog.InfoLevel
 		l.Flag = alog.WithDefault | alog.WithUnixTimeMs
+		if len(rotate) > 0 {
+			rw, err := NewRotateWriter(filename, rotate[0])
+			if err != nil {
+				l.Error(0).Err(err).Writes("failed to open")
+			} else {
+				l = l.SetOutput(rw)
+			}
+			return l
+		}
 		bw, err := NewBufWriter(filename)
 		if err != nil {
 			l.Error(0).Err( err).Writes("failed to open")
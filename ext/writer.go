@@ -0,0 +1,41 @@
+package ext
+
+import (
+	"github.com/gonyyi/alog"
+	"io"
+	"time"
+)
+
+// ErrClosed is returned when a writer is used after closed.
+// Closing a writer again is not an error, and returns nil.
+const ErrClosed = alog.Err("ext: writer closed")
+
+// ErrDisconnected is returned when a network writer is waiting to reconnect.
+const ErrDisconnected = alog.Err("ext: writer disconnected")
+
+// ErrBufferFull is returned when an entry is dropped as a buffer is full.
+const ErrBufferFull = alog.Err("ext: buffer full")
+
+// dialTimeout is the timeout of connecting for network writers.
+const dialTimeout = 5 * time.Second
+
+// toWriter converts io.Writer to alog.Writer. If w already
+// is alog.Writer, it will be used as is.
+func toWriter(w io.Writer) alog.Writer {
+	if w == nil {
+		return alog.Discard{}
+	}
+	if alw, ok := w.(alog.Writer); ok {
+		return alw
+	}
+	return alog.NewWriterFn(
+		func(b []byte, level alog.Level, tag alog.Tag) (int, error) {
+			return w.Write(b)
+		},
+		func() error {
+			if c, ok := w.(io.Closer); ok {
+				return c.Close()
+			}
+			return nil
+		})
+}
@@ -0,0 +1,219 @@
+package ext
+
+import (
+	"github.com/gonyyi/alog"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AsyncPolicy decides what an async writer does when its queue is full.
+type AsyncPolicy uint8
+
+const (
+	AsyncBlock      AsyncPolicy = iota // AsyncBlock blocks the caller until the queue has a room
+	AsyncDropNewest                    // AsyncDropNewest drops the entry being written
+	AsyncDropOldest                    // AsyncDropOldest drops the oldest entry in the queue
+)
+
+// NewAsyncWriter returns an alog.Writer which copies each formatted entry
+// into a bounded queue of the size, and writes it to w from a background
+// goroutine. When the queue is full, the policy decides whether to block
+// or drop. Flush and Close will drain the queue.
+//   eg. aw := ext.NewAsyncWriter(f, 1024, ext.AsyncDropOldest)
+//       al := alog.New(aw)
+//       defer al.Close()
+func NewAsyncWriter(w io.Writer, size int, policy AsyncPolicy) *asyncWriter {
+	if size < 1 {
+		size = 1
+	}
+	a := &asyncWriter{
+		w:      toWriter(w),
+		policy: policy,
+		items:  make([]asyncItem, size),
+		done:   make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// asyncItem is an entry in the queue. buf is reused.
+type asyncItem struct {
+	buf   []byte
+	level alog.Level
+	tag   alog.Tag
+}
+
+type asyncWriter struct {
+	w       alog.Writer
+	policy  AsyncPolicy
+	dropped uint64 // atomic
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  []asyncItem // ring buffer
+	head   int
+	n      int
+	busy   bool // busy is true while the background goroutine writes
+	closed bool
+	err    error // err is the last write error since Flush
+	done   chan struct{}
+	stop   chan struct{} // stop is for the drop reporter
+}
+
+// Write is for io.Writer compatibility; level and tag will be 0.
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLt(p, 0, 0)
+}
+
+// WriteLt copies p into the queue. The buffer p can be reused by
+// the caller as soon as this returns.
+func (a *asyncWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for a.n == len(a.items) && a.policy == AsyncBlock && !a.closed {
+		a.cond.Wait()
+	}
+	if a.closed {
+		return 0, ErrClosed
+	}
+
+	if a.n == len(a.items) {
+		atomic.AddUint64(&a.dropped, 1)
+		if a.policy == AsyncDropNewest {
+			return len(p), nil
+		}
+		// AsyncDropOldest: the oldest slot will be overwritten.
+		a.head = (a.head + 1) % len(a.items)
+		a.n--
+	}
+
+	it := &a.items[(a.head+a.n)%len(a.items)]
+	it.buf = append(it.buf[:0], p...)
+	it.level, it.tag = level, tag
+	a.n++
+	a.cond.Broadcast()
+	return len(p), nil
+}
+
+// run writes entries in the queue until closed and drained.
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	var spare []byte
+	for {
+		a.mu.Lock()
+		for a.n == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if a.n == 0 {
+			a.mu.Unlock()
+			return
+		}
+		// Swap the buffer with spare, so the slot can be
+		// reused while writing without holding the lock.
+		it := &a.items[a.head]
+		buf, level, tag := it.buf, it.level, it.tag
+		it.buf = spare[:0]
+		a.head = (a.head + 1) % len(a.items)
+		a.n--
+		a.busy = true
+		a.cond.Broadcast()
+		a.mu.Unlock()
+
+		_, err := a.w.WriteLt(buf, level, tag)
+		spare = buf
+
+		a.mu.Lock()
+		if err != nil {
+			a.err = err
+		}
+		a.busy = false
+		a.cond.Broadcast()
+		a.mu.Unlock()
+	}
+}
+
+// Dropped returns the total number of entries dropped.
+func (a *asyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// ReportDropped will call fn with the number of entries dropped since
+// the last call, every interval, if any has been dropped.
+// fn is called from a separate goroutine, and can log using a logger
+// writing to this writer. This should be called only once.
+func (a *asyncWriter) ReportDropped(interval time.Duration, fn func(dropped uint64)) *asyncWriter {
+	if fn == nil || interval <= 0 {
+		return a
+	}
+	a.mu.Lock()
+	if a.stop != nil || a.closed {
+		a.mu.Unlock()
+		return a
+	}
+	a.stop = make(chan struct{})
+	stop := a.stop
+	a.mu.Unlock()
+
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		var last uint64
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				if cur := a.Dropped(); cur != last {
+					fn(cur - last)
+					last = cur
+				}
+			}
+		}
+	}()
+	return a
+}
+
+// Flush blocks until the queue is drained and written. It returns the
+// last write error since the previous Flush, if any.
+func (a *asyncWriter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.n > 0 || a.busy {
+		a.cond.Wait()
+	}
+	err := a.err
+	a.err = nil
+	return err
+}
+
+// Close stops accepting new entries, drains the queue, and
+// closes the underlying writer. Closing again returns nil.
+func (a *asyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	if a.stop != nil {
+		close(a.stop)
+	}
+	a.cond.Broadcast()
+	a.mu.Unlock()
+
+	<-a.done
+
+	a.mu.Lock()
+	err := a.err
+	a.err = nil
+	a.mu.Unlock()
+
+	if cerr := a.w.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
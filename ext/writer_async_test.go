@@ -0,0 +1,133 @@
+package ext_test
+
+import (
+	"bytes"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateWriter blocks each write until released.
+type gateWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	release chan struct{}
+	closed  bool
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{
+		started: make(chan struct{}, 100),
+		release: make(chan struct{}, 100),
+	}
+}
+
+func (w *gateWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
+	w.started <- struct{}{}
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	return w.WriteLt(p, 0, 0)
+}
+
+func (w *gateWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	for _, tc := range []struct {
+		policy  ext.AsyncPolicy
+		exp     string
+		dropped uint64
+	}{
+		{ext.AsyncDropNewest, "abc", 1},
+		{ext.AsyncDropOldest, "acd", 1},
+	} {
+		gw := newGateWriter()
+		aw := ext.NewAsyncWriter(gw, 2, tc.policy)
+		aw.WriteLt([]byte("a"), 0, 0)
+		<-gw.started // "a" is being written; queue is empty
+		aw.WriteLt([]byte("b"), 0, 0)
+		aw.WriteLt([]byte("c"), 0, 0)
+		aw.WriteLt([]byte("d"), 0, 0) // queue is full
+		for i := 0; i < 3; i++ {
+			gw.release <- struct{}{}
+		}
+		if err := aw.Flush(); err != nil {
+			t.Errorf("AsyncWriter.Flush() // err=%s", err)
+		}
+		if act := gw.String(); act != tc.exp || aw.Dropped() != tc.dropped {
+			t.Errorf("AsyncWriter policy %d // exp=<%s>, act=<%s>, dropped=%d", tc.policy, tc.exp, act, aw.Dropped())
+		}
+		aw.Close()
+		if !gw.closed {
+			t.Errorf("AsyncWriter.Close() should close the writer")
+		}
+		if _, err := aw.WriteLt([]byte("e"), 0, 0); err != ext.ErrClosed {
+			t.Errorf("AsyncWriter.WriteLt() after close // err=%v", err)
+		}
+		if err := aw.Close(); err != nil {
+			t.Errorf("AsyncWriter.Close() again // err=%v", err)
+		}
+	}
+}
+
+func TestAsyncWriter_Block(t *testing.T) {
+	gw := newGateWriter()
+	aw := ext.NewAsyncWriter(gw, 1, ext.AsyncBlock)
+	var dropped uint64
+	aw.ReportDropped(time.Millisecond, func(n uint64) { dropped += n })
+
+	aw.WriteLt([]byte("a"), 0, 0)
+	<-gw.started
+	aw.WriteLt([]byte("b"), 0, 0) // queue is full now
+
+	done := make(chan struct{})
+	go func() {
+		aw.WriteLt([]byte("c"), 0, 0) // this should block
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("AsyncWriter.WriteLt() should block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	for i := 0; i < 3; i++ {
+		gw.release <- struct{}{}
+	}
+	<-done
+	if err := aw.Close(); err != nil {
+		t.Errorf("AsyncWriter.Close() // err=%s", err)
+	}
+	if act := gw.String(); act != "abc" || aw.Dropped() != 0 || dropped != 0 {
+		t.Errorf("AsyncWriter block // act=<%s>, dropped=%d", act, aw.Dropped())
+	}
+}
+
+func TestAsyncWriter_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	aw := ext.NewAsyncWriter(&buf, 16, ext.AsyncBlock)
+	al := alog.New(aw)
+	al.Flag = alog.WithLevel
+	for i := 0; i < 100; i++ {
+		al.Info().Int("i", i).Write()
+	}
+	al.Close()
+	if n := bytes.Count(buf.Bytes(), []byte("\n")); n != 100 {
+		t.Errorf("AsyncWriter with logger // exp=100, act=%d", n)
+	}
+}
@@ -9,6 +9,7 @@ type bufWriter struct {
 	filename string
 	file     *os.File
 	bufw     *bufio.Writer
+	closed   bool
 }
 
 func (b *bufWriter) Open(filename string) error {
@@ -18,11 +19,16 @@ func (b *bufWriter) Open(filename string) error {
 	if err != nil {
 		return err
 	}
+	b.closed = false
 	b.bufw = bufio.NewWriter(b.file)
 	return nil
 }
 
 func (b *bufWriter) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
 	if err := b.bufw.Flush(); err != nil {
 		return err
 	}
@@ -3,29 +3,126 @@ package ext
 import (
 	"github.com/gonyyi/alog"
 	"io"
+	"reflect"
 )
 
-func NewFilterWriter(w io.Writer, defaultLevel alog.Level, defaultTag alog.Tag) filterWriter {
-	return filterWriter{
-		w:        w,
-		defLevel: defaultLevel,
-		defTag:   defaultTag,
+// TagMatch decides how a Route matches tags of an entry.
+type TagMatch uint8
+
+const (
+	TagAny TagMatch = iota // TagAny matches when the entry has any of the tags
+	TagAll                 // TagAll matches when the entry has all of the tags
+)
+
+// Route is a rule for a routing writer. An entry is written to the
+// Writer when its level is Level or above, and its tag matches Tags.
+// 0 for Level or Tags will match any.
+type Route struct {
+	Level  alog.Level
+	Tags   alog.Tag
+	Match  TagMatch
+	Writer io.Writer
+}
+
+// match returns true if the level and tag meet the rule.
+func (r Route) match(level alog.Level, tag alog.Tag) bool {
+	if level < r.Level {
+		return false
+	}
+	if r.Tags == 0 {
+		return true
+	}
+	if r.Match == TagAll {
+		return tag&r.Tags == r.Tags
+	}
+	return tag&r.Tags != 0
+}
+
+// NewRouteWriter returns a writer which writes each entry to every
+// route matching the entry's level and tag.
+//   eg. w := ext.NewRouteWriter(
+//           ext.Route{Level: alog.WarnLevel, Writer: os.Stderr},
+//           ext.Route{Writer: appLog},
+//           ext.Route{Tags: tagDB, Writer: dbLog},
+//       )
+func NewRouteWriter(routes ...Route) *routeWriter {
+	w := &routeWriter{
+		routes: routes,
+		ws:     make([]alog.Writer, len(routes)),
+	}
+	for i := 0; i < len(routes); i++ {
+		w.ws[i] = toWriter(routes[i].Writer)
 	}
+	return w
 }
 
-type filterWriter struct {
-	w        io.Writer
+// NewFilterWriter returns a writer which writes entries with the level
+// or above, and with the tag if the tag is not 0. Writes without
+// a level and tag will use the default level and tag.
+func NewFilterWriter(w io.Writer, defaultLevel alog.Level, defaultTag alog.Tag) *routeWriter {
+	rw := NewRouteWriter(Route{
+		Level:  defaultLevel,
+		Tags:   defaultTag,
+		Writer: w,
+	})
+	rw.defLevel, rw.defTag = defaultLevel, defaultTag
+	return rw
+}
+
+type routeWriter struct {
+	routes   []Route
+	ws       []alog.Writer
 	defLevel alog.Level
 	defTag   alog.Tag
 }
 
-func (w filterWriter) Write(p []byte) (int, error) {
+// Write is for io.Writer compatibility. As there's no level or tag,
+// p will be routed with the default level and tag; they are 0 for
+// NewRouteWriter, so only routes matching any will get it.
+func (w *routeWriter) Write(p []byte) (int, error) {
 	return w.WriteLt(p, w.defLevel, w.defTag)
 }
 
-func (w filterWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
-	//if level >= w.defLevel {
-	return w.w.Write(p)
-	//}
-	//return 0, nil
+// WriteLt writes p to every route matching the level and tag.
+// It returns the first error from the routes, if any.
+func (w *routeWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
+	var err error
+	for i := 0; i < len(w.routes); i++ {
+		if w.routes[i].match(level, tag) {
+			if _, werr := w.ws[i].WriteLt(p, level, tag); werr != nil && err == nil {
+				err = werr
+			}
+		}
+	}
+	return len(p), err
+}
+
+// Close closes writers of the routes. A writer used by multiple
+// routes will be closed once.
+func (w *routeWriter) Close() error {
+	var err error
+	for i := 0; i < len(w.routes); i++ {
+		if w.closedBefore(i) {
+			continue
+		}
+		if cerr := w.ws[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// closedBefore returns true if the writer of route i is
+// also used by a route before i.
+func (w *routeWriter) closedBefore(i int) bool {
+	wi := w.routes[i].Writer
+	if wi == nil || !reflect.TypeOf(wi).Comparable() {
+		return false
+	}
+	for j := 0; j < i; j++ {
+		if w.routes[j].Writer == wi {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,78 @@
+package ext_test
+
+import (
+	"bytes"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"strings"
+	"testing"
+)
+
+func TestRouteWriter(t *testing.T) {
+	var errOut, all, db, dbDisk bytes.Buffer
+	al := alog.New(nil)
+	tagDB := al.NewTag("DB")
+	tagDisk := al.NewTag("Disk")
+
+	al = al.SetOutput(ext.NewRouteWriter(
+		ext.Route{Level: alog.WarnLevel, Writer: &errOut},
+		ext.Route{Writer: &all},
+		ext.Route{Tags: tagDB, Writer: &db},
+		ext.Route{Tags: tagDB | tagDisk, Match: ext.TagAll, Writer: &dbDisk},
+	))
+	al.Flag = alog.WithLevel
+	al.Control.Level = alog.TraceLevel
+
+	al.Debug(tagDB).Writes("1")
+	al.Info(tagDisk).Writes("2")
+	al.Warn(tagDB | tagDisk).Writes("3")
+	al.Error(0).Writes("4")
+
+	for _, tc := range []struct {
+		name string
+		buf  *bytes.Buffer
+		exp  []string
+	}{
+		{"stderr", &errOut, []string{"3", "4"}},
+		{"all", &all, []string{"1", "2", "3", "4"}},
+		{"db", &db, []string{"1", "3"}},
+		{"db+disk", &dbDisk, []string{"3"}},
+	} {
+		lines := strings.Split(strings.TrimSpace(tc.buf.String()), "\n")
+		if len(lines) != len(tc.exp) {
+			t.Fatalf("%s: expected %d lines, got %q", tc.name, len(tc.exp), tc.buf.String())
+		}
+		for i, exp := range tc.exp {
+			if !strings.Contains(lines[i], `"message":"`+exp+`"`) {
+				t.Errorf("%s: line %d: expected message %s, got %s", tc.name, i, exp, lines[i])
+			}
+		}
+	}
+}
+
+func TestFilterWriter(t *testing.T) {
+	var out bytes.Buffer
+	al := alog.New(ext.NewFilterWriter(&out, alog.InfoLevel, 0))
+	al.Control.Level = alog.TraceLevel
+
+	al.Debug().Writes("debug")
+	al.Info().Writes("info")
+	if s := out.String(); strings.Contains(s, "debug") || !strings.Contains(s, "info") {
+		t.Errorf("unexpected output: %s", s)
+	}
+
+	// Write without a level and tag uses the default level and tag.
+	out.Reset()
+	tagDB := al.NewTag("DB")
+	fw := ext.NewFilterWriter(&out, alog.WarnLevel, tagDB)
+	fw.Write([]byte("plain\n"))
+	if out.String() != "plain\n" {
+		t.Errorf("Write() with the default level and tag: %s", out.String())
+	}
+	rw := ext.NewRouteWriter(ext.Route{Level: alog.WarnLevel, Writer: &out})
+	out.Reset()
+	rw.Write([]byte("plain\n"))
+	if out.Len() != 0 {
+		t.Errorf("Write() should follow Route.Level: %s", out.String())
+	}
+}
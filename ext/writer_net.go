@@ -0,0 +1,323 @@
+package ext
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"github.com/gonyyi/alog"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrSpoolFull is returned when an entry can't be spooled as the spool is full.
+const ErrSpoolFull = alog.Err("ext: spool full")
+
+// NetConfig is a setting for a network writer.
+type NetConfig struct {
+	Network string      // Network is "tcp", "udp", "unix", etc.
+	Addr    string      // Addr is an address such as "localhost:5170"
+	TLS     *tls.Config // TLS, if set, will be used to connect with TLS
+
+	// Backoff is the initial wait before reconnecting after a failure,
+	// and it doubles up to MaxBackoff. Default is 100ms and 30s.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Spool is a file to keep entries written while disconnected.
+	// If empty, those entries will be dropped.
+	// MaxSpool is the maximum size of the spool in bytes; 0 for no limit.
+	Spool    string
+	MaxSpool int64
+
+	// OnError, if set, will be called with errors of connecting or writing.
+	OnError func(error)
+}
+
+// NewNetWriter returns a writer sending entries to a network address.
+// When disconnected, entries are kept in the spool file, and it reconnects
+// with an exponential backoff in the background, so writes are not blocked
+// by dialing. Once connected, spooled entries are sent first in order.
+// Entries left in the spool from a previous run will be sent as well, and
+// an incomplete entry at the end, such as from a crash, will be removed.
+// As spooled entries are removed after all sent, some may be sent again
+// if it fails in the middle.
+//   eg. w, err := ext.NewNetWriter(ext.NetConfig{
+//           Network: "tcp", Addr: "collector:5170",
+//           Spool: "/var/spool/app.spool", MaxSpool: 100<<20,
+//       })
+func NewNetWriter(cfg NetConfig) (*netWriter, error) {
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = 30 * time.Second
+		if cfg.MaxBackoff < cfg.Backoff {
+			cfg.MaxBackoff = cfg.Backoff
+		}
+	}
+	w := &netWriter{
+		cfg:     cfg,
+		backoff: cfg.Backoff,
+		stop:    make(chan struct{}),
+	}
+	if cfg.Spool != "" {
+		f, err := os.OpenFile(cfg.Spool, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+		size, err := scanSpool(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		w.spool = f
+		w.spoolSize = size
+	}
+
+	w.mu.Lock()
+	w.reconnect()
+	w.mu.Unlock()
+	return w, nil
+}
+
+type netWriter struct {
+	cfg NetConfig
+
+	mu      sync.Mutex
+	conn    net.Conn
+	backoff time.Duration // backoff is the wait for the next failure
+	dialing bool          // dialing is true while reconnecting in the background
+	stop    chan struct{} // stop is closed by Close to stop reconnecting
+	closed  bool
+	dropped uint64
+
+	// Spooled entries are stored as a 4 byte length and the entry.
+	spool     *os.File
+	spoolSize int64 // spoolSize is the size of the spool
+	spoolSent int64 // spoolSent is the size of entries sent from the spool
+	rbuf      []byte
+}
+
+// Write is for io.Writer compatibility.
+func (w *netWriter) Write(p []byte) (int, error) {
+	return w.WriteLt(p, 0, 0)
+}
+
+// WriteLt sends p. If disconnected, p will be spooled, and it
+// reconnects in the background.
+func (w *netWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrClosed
+	}
+
+	// When connected, the spool is always empty.
+	if w.conn != nil {
+		_, err := w.conn.Write(p)
+		if err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+		w.onError(err)
+	}
+	w.reconnect()
+	return w.addSpool(p)
+}
+
+// Flush returns ErrDisconnected when not connected, and the spooled
+// entries are waiting to be sent when reconnected in the background.
+func (w *netWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	if w.conn == nil {
+		w.reconnect()
+		return ErrDisconnected
+	}
+	return nil
+}
+
+// Dropped returns the number of entries dropped as it was
+// disconnected without a spool, or the spool was full.
+func (w *netWriter) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// Spooled returns the size of entries in the spool waiting to be sent.
+func (w *netWriter) Spooled() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.spoolSize - w.spoolSent
+}
+
+// dial connects to the address.
+func (w *netWriter) dial() (net.Conn, error) {
+	if w.cfg.TLS != nil {
+		return tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, w.cfg.Network, w.cfg.Addr, w.cfg.TLS)
+	}
+	return net.DialTimeout(w.cfg.Network, w.cfg.Addr, dialTimeout)
+}
+
+// reconnect starts reconnecting in the background, if not yet.
+// It must be called with w.mu held.
+func (w *netWriter) reconnect() {
+	if w.dialing || w.closed {
+		return
+	}
+	w.dialing = true
+	go w.redial()
+}
+
+// redial dials without holding the lock until connected or closed, and
+// once connected, sends entries in the spool. The first attempt is right
+// away, and next ones wait for the backoff.
+func (w *netWriter) redial() {
+	var wait time.Duration
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(wait):
+		}
+		conn, err := w.dial()
+
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		if err == nil {
+			w.conn = conn
+			if err = w.replay(); err == nil {
+				w.dialing, w.backoff = false, w.cfg.Backoff
+				w.mu.Unlock()
+				return
+			}
+			w.conn.Close()
+			w.conn = nil
+		}
+		wait = w.backoff
+		w.backoff *= 2
+		if w.backoff > w.cfg.MaxBackoff {
+			w.backoff = w.cfg.MaxBackoff
+		}
+		w.onError(err)
+		w.mu.Unlock()
+	}
+}
+
+// onError calls NetConfig.OnError if set.
+func (w *netWriter) onError(err error) {
+	if w.cfg.OnError != nil {
+		w.cfg.OnError(err)
+	}
+}
+
+// scanSpool returns the size of complete entries in the spool, and
+// truncates the rest, such as an entry partially written by a crash.
+func scanSpool(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	var hdr [4]byte
+	var off int64
+	for off+4 <= info.Size() {
+		if _, err := f.ReadAt(hdr[:], off); err != nil {
+			return 0, err
+		}
+		n := int64(binary.BigEndian.Uint32(hdr[:]))
+		if off+4+n > info.Size() {
+			break
+		}
+		off += 4 + n
+	}
+	if off < info.Size() {
+		if err := f.Truncate(off); err != nil {
+			return 0, err
+		}
+	}
+	return off, nil
+}
+
+// addSpool appends p to the spool.
+func (w *netWriter) addSpool(p []byte) (int, error) {
+	if w.spool == nil {
+		w.dropped++
+		return 0, ErrDisconnected
+	}
+	if w.cfg.MaxSpool > 0 && w.spoolSize+4+int64(len(p)) > w.cfg.MaxSpool {
+		w.dropped++
+		return 0, ErrSpoolFull
+	}
+	w.rbuf = append(w.rbuf[:0], 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(w.rbuf, uint32(len(p)))
+	w.rbuf = append(w.rbuf, p...)
+	if _, err := w.spool.WriteAt(w.rbuf, w.spoolSize); err != nil {
+		// remove a partial entry, if any.
+		w.spool.Truncate(w.spoolSize)
+		return 0, err
+	}
+	w.spoolSize += int64(len(w.rbuf))
+	return len(p), nil
+}
+
+// replay sends entries in the spool in order. Once all sent,
+// the spool will be truncated.
+func (w *netWriter) replay() error {
+	if w.spool == nil || w.spoolSize == 0 {
+		return nil
+	}
+	var hdr [4]byte
+	for w.spoolSent < w.spoolSize {
+		if _, err := w.spool.ReadAt(hdr[:], w.spoolSent); err != nil {
+			return err
+		}
+		n := int64(binary.BigEndian.Uint32(hdr[:]))
+		if int64(cap(w.rbuf)) < n {
+			w.rbuf = make([]byte, n)
+		}
+		w.rbuf = w.rbuf[:n]
+		if _, err := w.spool.ReadAt(w.rbuf, w.spoolSent+4); err != nil {
+			return err
+		}
+		if _, err := w.conn.Write(w.rbuf); err != nil {
+			return err
+		}
+		w.spoolSent += 4 + n
+	}
+	w.spoolSize, w.spoolSent = 0, 0
+	return w.spool.Truncate(0)
+}
+
+// Close closes the connection and the spool. Entries left in
+// the spool will be sent by the next NewNetWriter with the same spool.
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	close(w.stop)
+	var err error
+	if w.conn != nil {
+		err = w.conn.Close()
+	}
+	if w.spool != nil {
+		if serr := w.spool.Close(); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
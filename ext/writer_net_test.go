@@ -0,0 +1,206 @@
+package ext_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"github.com/gonyyi/alog/ext"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNetWriter(t *testing.T) {
+	// Take a free address, and keep it closed for a while.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	var errs int32
+	w, err := ext.NewNetWriter(ext.NetConfig{
+		Network:  "tcp",
+		Addr:     addr,
+		Backoff:  time.Millisecond,
+		Spool:    filepath.Join(t.TempDir(), "net.spool"),
+		MaxSpool: 2 * (4 + 2),
+		OnError:  func(error) { atomic.AddInt32(&errs, 1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("1\n"))
+	w.Write([]byte("2\n"))
+	if _, err := w.Write([]byte("3\n")); err != ext.ErrSpoolFull {
+		t.Errorf("expected ErrSpoolFull, got %v", err)
+	}
+	// It dials in the background, so writes are spooled without waiting.
+	for i := 0; i < 100 && atomic.LoadInt32(&errs) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if w.Dropped() != 1 || w.Spooled() != 12 || atomic.LoadInt32(&errs) == 0 {
+		t.Errorf("unexpected dropped: %d, spooled: %d, errs: %d", w.Dropped(), w.Spooled(), atomic.LoadInt32(&errs))
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+
+	// It reconnects in the background without a write, and
+	// spooled entries should come first.
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("4\n")); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	r := bufio.NewReader(c)
+	for _, exp := range []string{"1\n", "2\n", "4\n"} {
+		if s, err := r.ReadString('\n'); err != nil || s != exp {
+			t.Errorf("expected %q, got %q (%v)", exp, s, err)
+		}
+	}
+	if w.Spooled() != 0 {
+		t.Errorf("expected empty spool, got %d", w.Spooled())
+	}
+}
+
+func TestNetWriter_SpoolResume(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	spool := filepath.Join(t.TempDir(), "net.spool")
+	failed := make(chan struct{}, 1)
+	cfg := ext.NetConfig{Network: "tcp", Addr: addr, Spool: spool, OnError: func(error) {
+		select {
+		case failed <- struct{}{}:
+		default:
+		}
+	}}
+	w, err := ext.NewNetWriter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("a\n"))
+	// Dialing in the background should be done before listening.
+	<-failed
+	w.Close()
+
+	// A new writer with the same spool should send entries left.
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	w, err = ext.NewNetWriter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	if s, err := bufio.NewReader(c).ReadString('\n'); err != nil || s != "a\n" {
+		t.Errorf("expected %q, got %q (%v)", "a\n", s, err)
+	}
+}
+
+func TestNetWriter_SpoolPartial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// A spool with an entry, and another cut off in the middle.
+	spool := filepath.Join(t.TempDir(), "net.spool")
+	var b []byte
+	for _, s := range []string{"a\n", "bbbbbb\n"} {
+		var hdr [4]byte
+		binary.BigEndian.PutUint32(hdr[:], uint32(len(s)))
+		b = append(append(b, hdr[:]...), s...)
+	}
+	if err := os.WriteFile(spool, b[:len(b)-3], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := make(chan struct{})
+	w, err := ext.NewNetWriter(ext.NetConfig{Network: "tcp", Addr: addr, Spool: spool, Backoff: time.Hour,
+		OnError: func(error) { close(failed) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Write([]byte("c\n"))
+	// Dialing in the background should be done before listening.
+	<-failed
+	if w.Spooled() != 2*(4+2) {
+		t.Errorf("unexpected spooled: %d", w.Spooled())
+	}
+
+	w.Close()
+
+	// Entries after the cut are framed correctly.
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	w, err = ext.NewNetWriter(ext.NetConfig{Network: "tcp", Addr: addr, Spool: spool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	r := bufio.NewReader(c)
+	for _, exp := range []string{"a\n", "c\n"} {
+		if s, err := r.ReadString('\n'); err != nil || s != exp {
+			t.Errorf("expected %q, got %q (%v)", exp, s, err)
+		}
+	}
+}
+
+func TestNetWriter_NoBlock(t *testing.T) {
+	// Dialing an unroutable address may take until the timeout,
+	// but writes should be spooled without waiting for it.
+	w, err := ext.NewNetWriter(ext.NetConfig{
+		Network: "tcp",
+		Addr:    "10.255.255.1:5170",
+		Spool:   filepath.Join(t.TempDir(), "net.spool"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		w.Write([]byte("a\n"))
+	}
+	if d := time.Since(start); d > time.Second || w.Spooled() != 10*(4+2) {
+		t.Errorf("writes took %s, spooled: %d", d, w.Spooled())
+	}
+}
@@ -0,0 +1,211 @@
+package ext
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gonyyi/alog"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OTLPConfig is a setting for an OTLP/HTTP exporter.
+type OTLPConfig struct {
+	URL     string            // URL of the collector such as "http://localhost:4318/v1/logs"
+	Service string            // Service is the `service.name` resource attribute
+	Headers map[string]string // Headers are added to each request
+
+	BatchSize int           // BatchSize is the number of records per request; default 512
+	Interval  time.Duration // Interval is the maximum wait before sending; default 1s
+	MaxBuffer int           // MaxBuffer is the maximum bytes waiting to be sent; default 4MB
+
+	Client  *http.Client // Client; default is a client with 10s timeout
+	OnError func(error)  // OnError, if set, will be called when sending fails
+}
+
+// NewOTLPWriter returns a writer exporting log records to an OpenTelemetry
+// collector with OTLP/HTTP JSON. It takes records formatted by NewFormatterOTel,
+// batches them, and sends them from a background goroutine when the batch is
+// full or at every interval. When records waiting are more than MaxBuffer,
+// new records will be dropped. Flush and Close will send records waiting.
+//   eg. w := ext.NewOTLPWriter(ext.OTLPConfig{URL: "http://localhost:4318/v1/logs", Service: "api"})
+//       al = al.SetOutput(w).SetFormatter(ext.NewFormatterOTel())
+func NewOTLPWriter(cfg OTLPConfig) *otlpWriter {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 512
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.MaxBuffer < 1 {
+		cfg.MaxBuffer = 4 << 20
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	w := &otlpWriter{
+		cfg:  cfg,
+		full: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	w.prefix = w.appendPrefix(nil)
+	go w.run()
+	return w
+}
+
+type otlpWriter struct {
+	cfg    OTLPConfig
+	prefix []byte // prefix is the beginning of the request up to logRecords
+
+	mu      sync.Mutex
+	recs    []byte // recs are records joined by a comma
+	n       int    // n is the number of records in recs
+	dropped uint64
+	closed  bool
+
+	sendMu sync.Mutex // sendMu keeps requests in order
+	spare  []byte     // spare will be swapped with recs
+	body   []byte
+
+	full chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// appendPrefix appends the request body before log records.
+func (w *otlpWriter) appendPrefix(dst []byte) []byte {
+	dst = append(dst, `{"resourceLogs":[{"resource":{"attributes":[`...)
+	if w.cfg.Service != "" {
+		dst = append(dst, `{"key":"service.name","value":{"stringValue":`...)
+		dst = append(alog.AppendJSONString(dst, w.cfg.Service, true), '}', '}')
+	}
+	return append(dst, `]},"scopeLogs":[{"scope":{"name":"alog"},"logRecords":[`...)
+}
+
+// Write is for io.Writer compatibility.
+func (w *otlpWriter) Write(p []byte) (int, error) {
+	return w.WriteLt(p, 0, 0)
+}
+
+// WriteLt adds a record p to the batch.
+func (w *otlpWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
+	// A record is a JSON object ending with a newline.
+	rec := bytes.TrimSpace(p)
+	if len(rec) == 0 {
+		return len(p), nil
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, ErrClosed
+	}
+	if len(w.recs)+len(rec)+1 > w.cfg.MaxBuffer {
+		w.dropped++
+		w.mu.Unlock()
+		return 0, ErrBufferFull
+	}
+	if w.n > 0 {
+		w.recs = append(w.recs, ',')
+	}
+	w.recs = append(w.recs, rec...)
+	w.n++
+	full := w.n >= w.cfg.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of records dropped as the buffer was full.
+func (w *otlpWriter) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// run sends records at every interval, or when the batch is full.
+func (w *otlpWriter) run() {
+	defer close(w.done)
+	t := time.NewTicker(w.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+		case <-w.full:
+		}
+		if err := w.Flush(); err != nil && w.cfg.OnError != nil {
+			w.cfg.OnError(err)
+		}
+	}
+}
+
+// Flush sends all records waiting in a request.
+// If sending fails, those records will be dropped.
+func (w *otlpWriter) Flush() error {
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+
+	// Swap the buffer so writes won't wait while sending.
+	w.mu.Lock()
+	recs, n := w.recs, w.n
+	w.recs, w.n = w.spare[:0], 0
+	w.mu.Unlock()
+	w.spare = recs
+	if n == 0 {
+		return nil
+	}
+
+	w.body = append(append(append(w.body[:0], w.prefix...), recs...), "]}]}]}"...)
+	req, err := w.newRequest(w.body)
+	if err != nil {
+		return err
+	}
+	res, err := w.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("ext: otlp export failed: %s", res.Status)
+	}
+	return nil
+}
+
+// newRequest returns a POST request of the body.
+func (w *otlpWriter) newRequest(body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// Close stops the background goroutine and sends records waiting.
+func (w *otlpWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+	return w.Flush()
+}
@@ -0,0 +1,88 @@
+package ext_test
+
+import (
+	"encoding/json"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOTLPWriter(t *testing.T) {
+	type request struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []struct{ Key string }
+			}
+			ScopeLogs []struct {
+				LogRecords []struct {
+					Body struct{ StringValue string }
+				}
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var reqs []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		var req request
+		if err := json.Unmarshal(b, &req); err != nil {
+			t.Errorf("invalid json: %v: %s", err, b)
+		}
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Key") != "k1" {
+			t.Errorf("unexpected header: %v", r.Header)
+		}
+		mu.Lock()
+		reqs = append(reqs, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	w := ext.NewOTLPWriter(ext.OTLPConfig{
+		URL:       srv.URL,
+		Service:   "api",
+		Headers:   map[string]string{"X-Key": "k1"},
+		BatchSize: 2,
+		Interval:  time.Hour,
+	})
+	al := alog.New(w).Ext(ext.LogFmt.OTel())
+
+	// A full batch will be sent by the background.
+	al.Info().Writes("1")
+	al.Info().Writes("2")
+	for i := 0; i < 100; i++ {
+		mu.Lock()
+		n := len(reqs)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Close will send the rest.
+	al.Info().Writes("3")
+	if err := al.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var bodies []string
+	for _, req := range reqs {
+		if len(req.ResourceLogs) != 1 || req.ResourceLogs[0].Resource.Attributes[0].Key != "service.name" {
+			t.Fatalf("unexpected request: %+v", req)
+		}
+		for _, rec := range req.ResourceLogs[0].ScopeLogs[0].LogRecords {
+			bodies = append(bodies, rec.Body.StringValue)
+		}
+	}
+	if len(reqs) != 2 || len(bodies) != 3 || bodies[0] != "1" || bodies[1] != "2" || bodies[2] != "3" {
+		t.Errorf("unexpected requests: %d, records: %v", len(reqs), bodies)
+	}
+}
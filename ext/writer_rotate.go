@@ -0,0 +1,363 @@
+package ext
+
+import (
+	"compress/gzip"
+	"github.com/gonyyi/alog"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateEvery is an interval of time based rotation.
+type RotateEvery uint8
+
+const (
+	RotateNone   RotateEvery = iota // RotateNone disables time based rotation
+	RotateHourly                    // RotateHourly rotates at every hour boundary
+	RotateDaily                     // RotateDaily rotates at every midnight
+)
+
+// rotateTimeFormat is used for names of rotated files; it sorts by time.
+const rotateTimeFormat = "20060102T150405.000"
+
+// RotateConfig is a setting for a rotating file writer.
+type RotateConfig struct {
+	MaxSize    int64         // MaxSize is the maximum size of a file in bytes; 0 for no limit
+	Every      RotateEvery   // Every is for time based rotation
+	UTC        bool          // UTC uses UTC boundaries and names; otherwise local time
+	MaxBackups int           // MaxBackups is the number of rotated files to keep; 0 keeps all
+	MaxAge     time.Duration // MaxAge is how long rotated files are kept; 0 keeps all
+	Compress   bool          // Compress will gzip rotated files in the background
+	OnError    func(error)   // OnError, if set, will be called when rotating, compressing or removing fails
+}
+
+// NewRotateWriter returns a file writer which appends to the filename, and
+// rotates it by the size and/or time. Rotated files will be renamed with
+// the start of the period they cover such as `app-20210308T000000.000.log`
+// for time based rotation, or the time of rotation otherwise, and then
+// compressed and removed by the retention in the background. The retention
+// is also applied to existing files when opened. Files failed to compress
+// will be kept as is, and retried at the next rotation.
+//   eg. w, err := ext.NewRotateWriter("app.log", ext.RotateConfig{
+//           MaxSize: 100<<20, Every: ext.RotateDaily, MaxBackups: 7, Compress: true,
+//       })
+func NewRotateWriter(filename string, cfg RotateConfig) (*rotateWriter, error) {
+	r := &rotateWriter{
+		filename: filename,
+		cfg:      cfg,
+		loc:      time.Local,
+		mill:     make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if cfg.UTC {
+		r.loc = time.UTC
+	}
+	if err := r.open(time.Now()); err != nil {
+		return nil, err
+	}
+	// Apply the retention to files rotated before.
+	select {
+	case r.mill <- struct{}{}:
+	default: // already signaled by rotate
+	}
+	go r.runMill()
+	return r, nil
+}
+
+type rotateWriter struct {
+	filename string
+	cfg      RotateConfig
+	loc      *time.Location
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	start  time.Time // start is the start of the period of the file
+	next   time.Time // next is the time of the next rotation
+	closed bool
+
+	mill     chan struct{} // mill signals the background to compress and remove
+	millDone chan struct{}
+}
+
+// open opens the file to append. If the file was last written
+// before the current period, it will be rotated first.
+func (r *rotateWriter) open(now time.Time) error {
+	info, err := os.Stat(r.filename)
+	if err == nil && r.cfg.Every != RotateNone && info.ModTime().Before(r.periodStart(now)) {
+		r.start = r.periodStart(info.ModTime())
+		return r.rotateOrKeep(now)
+	}
+	f, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.size = 0
+	if info != nil {
+		r.size = info.Size()
+	}
+	r.start = r.periodStart(now)
+	r.next = r.nextRotation(now)
+	return nil
+}
+
+// periodStart returns the start of the period of now.
+func (r *rotateWriter) periodStart(now time.Time) time.Time {
+	t := now.In(r.loc)
+	switch r.cfg.Every {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, r.loc)
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, r.loc)
+	}
+	return time.Time{}
+}
+
+// nextRotation returns the time of the next rotation, or a zero time.
+func (r *rotateWriter) nextRotation(now time.Time) time.Time {
+	t := r.periodStart(now)
+	switch r.cfg.Every {
+	case RotateHourly:
+		return t.Add(time.Hour)
+	case RotateDaily:
+		return t.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// backupName returns a name for the rotated file which does not exist.
+// It is the start of the period of the file for time based rotation,
+// or the time of rotation otherwise.
+func (r *rotateWriter) backupName(now time.Time) string {
+	ext := filepath.Ext(r.filename)
+	prefix := r.filename[:len(r.filename)-len(ext)]
+	t := now.In(r.loc)
+	if r.cfg.Every != RotateNone && !r.start.IsZero() {
+		t = r.start.In(r.loc)
+	}
+	for {
+		name := prefix + "-" + t.Format(rotateTimeFormat) + ext
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err := os.Stat(name + ".gz"); os.IsNotExist(err) {
+				return name
+			}
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// rotate renames the current file, and opens a new one. If the file
+// cannot be renamed, it will be reopened to append, and the error
+// will be returned.
+func (r *rotateWriter) rotate(now time.Time) error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+		r.file = nil
+	}
+	if _, err := os.Stat(r.filename); err == nil {
+		if err := os.Rename(r.filename, r.backupName(now)); err != nil {
+			f, oerr := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if oerr == nil {
+				r.file = f
+				if info, serr := f.Stat(); serr == nil {
+					r.size = info.Size()
+				}
+				// try again at the next period, not at every write.
+				r.next = r.nextRotation(now)
+			}
+			return err
+		}
+	}
+	f, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.size = 0
+	r.start = r.periodStart(now)
+	r.next = r.nextRotation(now)
+
+	select {
+	case r.mill <- struct{}{}:
+	default: // already signaled
+	}
+	return nil
+}
+
+// rotateOrKeep rotates the file. If it failed but the current file
+// was reopened, the error will be reported to OnError, and the
+// current file will be used.
+func (r *rotateWriter) rotateOrKeep(now time.Time) error {
+	err := r.rotate(now)
+	if err != nil && r.file != nil {
+		r.onError(err)
+		return nil
+	}
+	return err
+}
+
+// Rotate will rotate the file now.
+func (r *rotateWriter) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrClosed
+	}
+	return r.rotate(time.Now())
+}
+
+// Write is for io.Writer compatibility.
+func (r *rotateWriter) Write(p []byte) (int, error) {
+	return r.WriteLt(p, 0, 0)
+}
+
+// WriteLt writes p to the file, rotating it first if needed.
+func (r *rotateWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, ErrClosed
+	}
+
+	now := time.Now()
+	// file is nil when a rotation failed to open a new file.
+	if r.file == nil || (!r.next.IsZero() && !now.Before(r.next)) ||
+		(r.cfg.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.cfg.MaxSize) {
+		if err := r.rotateOrKeep(now); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the file, and waits for the background
+// compression and removal to finish. Closing again returns nil.
+func (r *rotateWriter) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+	}
+	close(r.mill)
+	r.mu.Unlock()
+
+	<-r.millDone
+	return err
+}
+
+// rotated is a rotated file.
+type rotated struct {
+	name string
+	t    time.Time
+}
+
+// backups returns rotated files, newest first.
+func (r *rotateWriter) backups() []rotated {
+	ext := filepath.Ext(r.filename)
+	prefix := filepath.Base(r.filename[:len(r.filename)-len(ext)]) + "-"
+	dir := filepath.Dir(r.filename)
+
+	fis, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var out []rotated
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], ".gz"), ext)
+		t, err := time.ParseInLocation(rotateTimeFormat, ts, r.loc)
+		if err != nil {
+			continue
+		}
+		out = append(out, rotated{name: filepath.Join(dir, name), t: t})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].t.After(out[j].t)
+	})
+	return out
+}
+
+// runMill compresses and removes rotated files when signaled.
+func (r *rotateWriter) runMill() {
+	defer close(r.millDone)
+	for range r.mill {
+		r.millOnce()
+	}
+}
+
+func (r *rotateWriter) millOnce() {
+	files := r.backups()
+	now := time.Now()
+	for i, f := range files {
+		if (r.cfg.MaxBackups > 0 && i >= r.cfg.MaxBackups) ||
+			(r.cfg.MaxAge > 0 && now.Sub(f.t) > r.cfg.MaxAge) {
+			if err := os.Remove(f.name); err != nil {
+				r.onError(err)
+			}
+			continue
+		}
+		if r.cfg.Compress && !strings.HasSuffix(f.name, ".gz") {
+			// On failure, the file is kept, and tried again next time.
+			if err := gzipFile(f.name); err != nil {
+				r.onError(err)
+			}
+		}
+	}
+}
+
+// onError calls RotateConfig.OnError if set.
+func (r *rotateWriter) onError(err error) {
+	if r.cfg.OnError != nil {
+		r.cfg.OnError(err)
+	}
+}
+
+// gzipFile compresses src to src.gz, and removes src. The compressed
+// file is written to a temporary file first, then renamed.
+func gzipFile(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := src + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, src+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
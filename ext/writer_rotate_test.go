@@ -0,0 +1,166 @@
+package ext_test
+
+import (
+	"compress/gzip"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	w, err := ext.NewRotateWriter(filename, ext.RotateConfig{MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); err != ext.ErrClosed {
+		t.Errorf("RotateWriter.Write() after close // err=%v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("RotateWriter.Close() again // err=%v", err)
+	}
+
+	// current file + 2 backups ("a" is removed by MaxBackups)
+	names, contents := readDir(t, dir)
+	if len(names) != 3 || contents["app.log"] != "dddddd\n" {
+		t.Fatalf("unexpected files: %v", contents)
+	}
+	var backups []string
+	for _, name := range names {
+		if name != "app.log" {
+			if !strings.HasPrefix(name, "app-") || !strings.HasSuffix(name, ".log") {
+				t.Errorf("unexpected backup name: %s", name)
+			}
+			backups = append(backups, contents[name])
+		}
+	}
+	if strings.Join(backups, "") != "bbbbbb\ncccccc\n" {
+		t.Errorf("unexpected backups: %v", backups)
+	}
+}
+
+func TestRotateWriter_Compress(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	// Existing file will be appended.
+	os.WriteFile(filename, []byte("old\n"), 0644)
+
+	al := alog.New(nil).Ext(ext.LogMode.Prod(filename, ext.RotateConfig{Compress: true, UTC: true}))
+	al.Flag = alog.WithLevel
+	al.Info().Writes("first")
+	if rw, ok := al.Output().(interface{ Rotate() error }); !ok {
+		t.Fatalf("LogMode.Prod() should use rotating writer")
+	} else if err := rw.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	al.Info().Writes("second")
+	al.Close()
+
+	names, contents := readDir(t, dir)
+	if len(names) != 2 || contents["app.log"] != `{"level":"info","message":"second"}`+"\n" {
+		t.Fatalf("unexpected files: %v", contents)
+	}
+	if !strings.HasSuffix(names[0], ".log.gz") {
+		t.Errorf("unexpected backup name: %s", names[0])
+	}
+	if exp := "old\n" + `{"level":"info","message":"first"}` + "\n"; contents[names[0]] != exp {
+		t.Errorf("unexpected backup // exp=<%s>, act=<%s>", exp, contents[names[0]])
+	}
+}
+
+func TestRotateWriter_Startup(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	// Backups from before are removed by the retention when opened, and
+	// the file written yesterday is named after the day it covers.
+	os.WriteFile(filepath.Join(dir, "app-20210306T000000.000.log"), []byte("a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "app-20210307T000000.000.log"), []byte("b\n"), 0644)
+	os.WriteFile(filename, []byte("c\n"), 0644)
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	os.Chtimes(filename, yesterday, yesterday)
+
+	w, err := ext.NewRotateWriter(filename, ext.RotateConfig{Every: ext.RotateDaily, UTC: true, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("d\n"))
+	w.Close()
+
+	names, contents := readDir(t, dir)
+	backup := "app-" + yesterday.Format("20060102") + "T000000.000.log"
+	if len(names) != 3 || contents["app-20210307T000000.000.log"] != "b\n" ||
+		contents[backup] != "c\n" || contents["app.log"] != "d\n" {
+		t.Errorf("unexpected files: %v", contents)
+	}
+}
+
+func TestRotateWriter_OnError(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	// A directory in place of the temporary file makes compression fail.
+	os.WriteFile(filepath.Join(dir, "app-20210307T000000.000.log"), []byte("a\n"), 0644)
+	os.Mkdir(filepath.Join(dir, "app-20210307T000000.000.log.gz.tmp"), 0755)
+
+	var errs int
+	w, err := ext.NewRotateWriter(filename, ext.RotateConfig{Compress: true, OnError: func(error) { errs++ }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	if errs != 1 {
+		t.Errorf("OnError should be called once: %d", errs)
+	}
+	// The file is kept to try again.
+	if _, contents := readDir(t, dir); contents["app-20210307T000000.000.log"] != "a\n" {
+		t.Errorf("unexpected files: %v", contents)
+	}
+}
+
+// readDir returns sorted names and contents of files in dir.
+// Gzip files will be decompressed.
+func readDir(t *testing.T, dir string) ([]string, map[string]string) {
+	fis, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	contents := make(map[string]string)
+	for _, fi := range fis {
+		f, err := os.Open(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = f
+		if strings.HasSuffix(fi.Name(), ".gz") {
+			if r, err = gzip.NewReader(f); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b, _ := io.ReadAll(r)
+		f.Close()
+		names = append(names, fi.Name())
+		contents[fi.Name()] = string(b)
+	}
+	sort.Strings(names)
+	return names, contents
+}
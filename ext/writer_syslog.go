@@ -0,0 +1,316 @@
+package ext
+
+import (
+	"github.com/gonyyi/alog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogFormat is a format of syslog messages.
+type SyslogFormat uint8
+
+const (
+	SyslogRFC5424 SyslogFormat = iota // SyslogRFC5424 is the current syslog protocol
+	SyslogRFC3164                     // SyslogRFC3164 is the legacy BSD syslog protocol
+)
+
+// Syslog facilities
+const (
+	SyslogKern   = 0
+	SyslogUser   = 1
+	SyslogDaemon = 3
+	SyslogAuth   = 4
+	SyslogLocal0 = 16
+	SyslogLocal1 = 17
+	SyslogLocal2 = 18
+	SyslogLocal3 = 19
+	SyslogLocal4 = 20
+	SyslogLocal5 = 21
+	SyslogLocal6 = 22
+	SyslogLocal7 = 23
+)
+
+// syslogSDID is the default SD-ID for tags. 32473 is the private
+// enterprise number reserved for documentation and examples.
+const syslogSDID = "alog@32473"
+
+// SyslogConfig is a setting for a syslog writer.
+type SyslogConfig struct {
+	Network  string       // Network is "udp", "tcp", "unix" or "unixgram"
+	Addr     string       // Addr is an address such as "localhost:514" or "/dev/log"
+	Format   SyslogFormat // Format is RFC 5424 by default
+	Facility int          // Facility such as SyslogUser or SyslogLocal0
+	Hostname string       // Hostname; if empty, os.Hostname will be used
+	AppName  string       // AppName; if empty, the name of the program will be used
+
+	// Tags is used to get names of tags; it's usually al.Control.Bucket().
+	// With RFC 5424, tag names will be added as structured data such as
+	// `[alog@32473 tag="DB,Disk"]`. If TagAsApp is set, the first tag name
+	// will be used as the app-name instead, for both formats.
+	Tags     *alog.TagBucket
+	TagAsApp bool
+	SDID     string // SDID is the SD-ID of tags; default is "alog@32473"
+
+	// Backoff is the initial wait before reconnecting after a failure,
+	// and it doubles up to MaxBackoff. Default is 100ms and 30s.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NewSyslogWriter returns a writer sending each entry as a syslog message.
+// The severity is from the level of the entry. Over TCP and stream unix
+// sockets, messages are framed by octet counting (RFC 6587). When the
+// connection fails, it reconnects with a backoff in the background, so
+// writes are not blocked by dialing; writes until then will return
+// ErrDisconnected.
+//   eg. w, err := ext.NewSyslogWriter(ext.SyslogConfig{
+//           Network: "udp", Addr: "localhost:514", Facility: ext.SyslogLocal0,
+//           Tags: al.Control.Bucket(),
+//       })
+//       al = al.SetOutput(w)
+func NewSyslogWriter(cfg SyslogConfig) (*syslogWriter, error) {
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+		if cfg.Hostname == "" {
+			cfg.Hostname = "-"
+		}
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = filepath.Base(os.Args[0])
+	}
+	if cfg.SDID == "" {
+		cfg.SDID = syslogSDID
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = 30 * time.Second
+		if cfg.MaxBackoff < cfg.Backoff {
+			cfg.MaxBackoff = cfg.Backoff
+		}
+	}
+	w := &syslogWriter{
+		cfg:     cfg,
+		pid:     strconv.Itoa(os.Getpid()),
+		stream:  cfg.Network != "udp" && cfg.Network != "udp4" && cfg.Network != "udp6" && cfg.Network != "unixgram",
+		backoff: cfg.Backoff,
+		stop:    make(chan struct{}),
+	}
+	conn, err := net.DialTimeout(cfg.Network, cfg.Addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	return w, nil
+}
+
+type syslogWriter struct {
+	cfg    SyslogConfig
+	pid    string
+	stream bool
+
+	mu      sync.Mutex
+	conn    net.Conn
+	buf     []byte
+	tags    []byte // tags holds names of tags of the current message
+	backoff time.Duration // backoff is the wait for the next failure
+	dialing bool          // dialing is true while reconnecting in the background
+	stop    chan struct{} // stop is closed by Close to stop reconnecting
+	closed  bool
+}
+
+// SyslogSeverity returns the syslog severity of the level.
+// Entries without a level will be informational.
+func SyslogSeverity(level alog.Level) int {
+	switch level {
+	case alog.TraceLevel, alog.DebugLevel:
+		return 7 // debug
+	case alog.WarnLevel:
+		return 4 // warning
+	case alog.ErrorLevel:
+		return 3 // err
+	case alog.FatalLevel:
+		return 2 // crit
+	default:
+		return 6 // info
+	}
+}
+
+// reconnect starts reconnecting in the background, if not yet.
+// It must be called with w.mu held.
+func (w *syslogWriter) reconnect() {
+	if w.dialing || w.closed {
+		return
+	}
+	w.dialing = true
+	go w.redial()
+}
+
+// redial dials without holding the lock until connected or closed.
+// The first attempt is right away, and next ones wait for the backoff.
+func (w *syslogWriter) redial() {
+	var wait time.Duration
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(wait):
+		}
+		conn, err := net.DialTimeout(w.cfg.Network, w.cfg.Addr, dialTimeout)
+
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		if err == nil {
+			w.conn, w.dialing, w.backoff = conn, false, w.cfg.Backoff
+			w.mu.Unlock()
+			return
+		}
+		wait = w.backoff
+		w.backoff *= 2
+		if w.backoff > w.cfg.MaxBackoff {
+			w.backoff = w.cfg.MaxBackoff
+		}
+		w.mu.Unlock()
+	}
+}
+
+// Write is for io.Writer compatibility; p will be sent with info severity.
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLt(p, 0, 0)
+}
+
+// WriteLt sends p as a syslog message. If disconnected or sending fails,
+// it returns an error, and reconnects in the background.
+func (w *syslogWriter) WriteLt(p []byte, level alog.Level, tag alog.Tag) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrClosed
+	}
+
+	now := time.Now()
+	w.buf = w.format(w.buf[:0], now, p, level, tag)
+
+	if w.conn == nil {
+		w.reconnect()
+		return 0, ErrDisconnected
+	}
+	if _, err := w.conn.Write(w.buf); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.reconnect()
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// format appends a syslog message of p to dst.
+func (w *syslogWriter) format(dst []byte, now time.Time, p []byte, level alog.Level, tag alog.Tag) []byte {
+	// Trailing newlines are not a part of the message.
+	for len(p) > 0 && (p[len(p)-1] == '\n' || p[len(p)-1] == '\r') {
+		p = p[:len(p)-1]
+	}
+
+	dst = append(dst, '<')
+	dst = strconv.AppendInt(dst, int64(w.cfg.Facility*8+SyslogSeverity(level)), 10)
+	dst = append(dst, '>')
+
+	app := []byte(nil)
+	w.tags = w.tags[:0]
+	if w.cfg.Tags != nil && tag != 0 {
+		w.tags = w.cfg.Tags.AppendTag(w.tags, tag)
+		if w.cfg.TagAsApp {
+			app = firstTag(w.tags)
+		}
+	}
+
+	if w.cfg.Format == SyslogRFC3164 {
+		dst = now.AppendFormat(dst, time.Stamp)
+		dst = append(append(dst, ' '), w.cfg.Hostname...)
+		dst = w.appendApp(append(dst, ' '), app)
+		dst = append(append(append(dst, '['), w.pid...), "]: "...)
+	} else {
+		dst = append(dst, '1', ' ')
+		dst = now.AppendFormat(dst, "2006-01-02T15:04:05.000000Z07:00")
+		dst = append(append(dst, ' '), w.cfg.Hostname...)
+		dst = w.appendApp(append(dst, ' '), app)
+		dst = append(append(dst, ' '), w.pid...)
+		dst = append(dst, " - "...) // MSGID
+		if len(w.tags) > 0 && !w.cfg.TagAsApp {
+			dst = append(append(append(dst, '['), w.cfg.SDID...), ` tag="`...)
+			dst = appendSDValue(dst, w.tags)
+			dst = append(dst, `"]`...)
+		} else {
+			dst = append(dst, '-') // no structured data
+		}
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, p...)
+
+	if w.stream {
+		// RFC 6587 octet counting: `LEN SP MSG`
+		n := len(dst)
+		var pre [21]byte
+		l := strconv.AppendInt(pre[:0], int64(n), 10)
+		l = append(l, ' ')
+		dst = append(dst, l...)
+		copy(dst[len(l):], dst[:n])
+		copy(dst, l)
+	}
+	return dst
+}
+
+// firstTag returns the first name of comma separated tag names.
+func firstTag(tags []byte) []byte {
+	for i := 0; i < len(tags); i++ {
+		if tags[i] == ',' {
+			return tags[:i]
+		}
+	}
+	return tags
+}
+
+// appendApp appends the app-name; app from a tag if given.
+func (w *syslogWriter) appendApp(dst []byte, app []byte) []byte {
+	if len(app) > 0 {
+		return append(dst, app...)
+	}
+	return append(dst, w.cfg.AppName...)
+}
+
+// appendSDValue appends s as an SD-PARAM value; `"`, `\` and `]` are escaped.
+func appendSDValue(dst []byte, s []byte) []byte {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' || s[i] == ']' {
+			dst = append(dst, '\\')
+		}
+		dst = append(dst, s[i])
+	}
+	return dst
+}
+
+// Close closes the connection. Writes after Close will return ErrClosed.
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	close(w.stop)
+	if w.conn != nil {
+		return w.conn.Close()
+	}
+	return nil
+}
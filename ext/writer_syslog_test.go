@@ -0,0 +1,154 @@
+package ext_test
+
+import (
+	"bufio"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogWriter_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+
+	al := alog.New(nil)
+	tagDB := al.NewTag("DB")
+	w, err := ext.NewSyslogWriter(ext.SyslogConfig{
+		Network:  "udp",
+		Addr:     pc.LocalAddr().String(),
+		Facility: ext.SyslogLocal0,
+		Hostname: "host1",
+		AppName:  "app1",
+		Tags:     al.Control.Bucket(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	al = al.SetOutput(w)
+	al.Flag = 0
+	defer al.Close()
+
+	read := func() string {
+		buf := make([]byte, 1024)
+		pc.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+
+	al.Error(tagDB).Writes("failed")
+	// local0(16)*8 + err(3) = 131
+	msg := read()
+	if !strings.HasPrefix(msg, "<131>1 ") ||
+		!strings.Contains(msg, ` host1 app1 `) ||
+		!strings.HasSuffix(msg, ` - [alog@32473 tag="DB"] {"message":"failed"}`) {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	al.Info().Writes("ok")
+	if msg := read(); !strings.HasPrefix(msg, "<134>1 ") || !strings.HasSuffix(msg, ` - - {"message":"ok"}`) {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func TestSyslogWriter_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+
+	al := alog.New(nil)
+	tagDB := al.NewTag("DB")
+	w, err := ext.NewSyslogWriter(ext.SyslogConfig{
+		Network:  "tcp",
+		Addr:     ln.Addr().String(),
+		Format:   ext.SyslogRFC3164,
+		Facility: ext.SyslogUser,
+		Hostname: "host1",
+		AppName:  "app1",
+		Tags:     al.Control.Bucket(),
+		TagAsApp: true,
+		Backoff:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	al = al.SetOutput(w)
+	al.Flag = 0
+	defer al.Close()
+
+	// read reads an octet counted message.
+	read := func(r *bufio.Reader) string {
+		l, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(l))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+
+	c1 := <-conns
+	c1.SetReadDeadline(time.Now().Add(time.Second))
+	r := bufio.NewReader(c1)
+	al.Warn(tagDB).Writes("first")
+	al.Warn(tagDB).Writes("second")
+	for _, exp := range []string{"first", "second"} {
+		// user(1)*8 + warning(4) = 12
+		msg := read(r)
+		if !strings.HasPrefix(msg, "<12>") || !strings.HasSuffix(msg, ` host1 DB[`+strconv.Itoa(os.Getpid())+`]: {"message":"`+exp+`"}`) {
+			t.Errorf("unexpected message: %s", msg)
+		}
+	}
+
+	// Once the server drops the connection, it should reconnect.
+	c1.Close()
+	var c2 net.Conn
+	for i := 0; c2 == nil && i < 100; i++ {
+		al.Info().Writes("again")
+		select {
+		case c2 = <-conns:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if c2 == nil {
+		t.Fatal("expected to reconnect")
+	}
+	defer c2.Close()
+	// It reconnects in the background; write once connected.
+	time.Sleep(20 * time.Millisecond)
+	al.Info().Writes("again")
+	c2.SetReadDeadline(time.Now().Add(time.Second))
+	if msg := read(bufio.NewReader(c2)); !strings.HasSuffix(msg, `{"message":"again"}`) {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
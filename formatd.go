@@ -1,6 +1,9 @@
 package alog
 
-import "strconv"
+import (
+	"strconv"
+	"time"
+)
 
 type formatd struct{}
 
@@ -49,6 +52,10 @@ func (formatd) addValInt(dst []byte, i int64) []byte {
 	return append(strconv.AppendInt(dst, i, 10), ',')
 }
 
+func (formatd) addValUint(dst []byte, i uint64) []byte {
+	return append(strconv.AppendUint(dst, i, 10), ',')
+}
+
 func (formatd) addValFloat(dst []byte, f float64) []byte {
 	return append(strconv.AppendFloat(dst, f, 'f', -1, 64), ',')
 }
@@ -62,6 +69,11 @@ func (formatd) addLevel(dst []byte, level Level) []byte {
 	return append(append(append(dst, '"'), level.Name()...), '"', ',')
 }
 
+func (formatd) addCaller(dst []byte, file string, line int) []byte {
+	dst = appendCaller(append(dst, '"'), file, line)
+	return append(dst, '"', ',')
+}
+
 func (formatd) addTimeUnix(dst []byte, ts int64) []byte {
 	return append(strconv.AppendInt(dst, ts, 10), ',')
 }
@@ -81,3 +93,108 @@ func (formatd) addTimeMs(dst []byte, h, m, s, ns int) []byte {
 func (formatd) addTimeDay(dst []byte, weekday int) []byte {
 	return append(strconv.AppendInt(dst, int64(weekday), 10), ',')
 }
+
+// addKVs will append key value items to dst in JSON format.
+// Each item will end with a comma, the last one will be replaced by addEnd.
+func (f formatd) addKVs(dst []byte, kvs []KeyValue) []byte {
+	for i := 0; i < len(kvs); i++ {
+		// Set name
+		dst = f.addKey(dst, kvs[i].Key)
+		dst = f.addVal(dst, &kvs[i])
+	}
+	return dst
+}
+
+// addVal will append a value of the KeyValue followed by a comma.
+func (f formatd) addVal(dst []byte, kv *KeyValue) []byte {
+	switch kv.Vtype {
+	case KvInt:
+		dst = f.addValInt(dst, kv.Vint)
+	case KvString:
+		if ok, _ := f.isSimpleStr(kv.Vstr); ok {
+			dst = f.addValStringUnsafe(dst, kv.Vstr)
+		} else {
+			dst = f.addValString(dst, kv.Vstr)
+		}
+	case KvBool:
+		dst = f.addValBool(dst, kv.Vbool)
+	case KvFloat64:
+		dst = f.addValFloat(dst, kv.Vf64)
+	case KvError:
+		if kv.Verr != nil {
+			errStr := kv.Verr.Error()
+			if ok, _ := f.isSimpleStr(errStr); ok {
+				dst = f.addValStringUnsafe(dst, errStr)
+			} else {
+				dst = f.addValString(dst, errStr)
+			}
+		} else {
+			dst = append(dst, `null,`...)
+		}
+	case KvStack:
+		dst = f.addValStack(dst, kv.Vkvs)
+	case KvUint:
+		dst = f.addValUint(dst, uint64(kv.Vint))
+	case KvDuration:
+		dst = append(AppendDuration(append(dst, '"'), time.Duration(kv.Vint)), '"', ',')
+	case KvTime:
+		if kv.Vany != nil {
+			dst = append(kv.Time().AppendFormat(append(dst, '"'), time.RFC3339Nano), '"', ',')
+		} else {
+			dst = append(dst, `null,`...)
+		}
+	case KvBytes:
+		dst = append(appendBytes(dst, kv.Vbyte, true), ',')
+	case KvHex:
+		dst = append(AppendHex(append(dst, '"'), kv.Vbyte), '"', ',')
+	case KvBase64:
+		dst = append(AppendBase64(append(dst, '"'), kv.Vbyte), '"', ',')
+	case KvStrs, KvInts, KvFloats, KvBools, KvErrs:
+		dst = f.addValArr(dst, kv.Vkvs)
+	case KvDict:
+		dst = f.addValDict(dst, kv.Vkvs)
+	case KvAny:
+		dst = f.addValAny(dst, kv.Vany)
+	default:
+		dst = append(dst, `null,`...)
+	}
+	return dst
+}
+
+// addValArr will append array items as a JSON array.
+func (f formatd) addValArr(dst []byte, items []KeyValue) []byte {
+	dst = append(dst, '[')
+	for i := 0; i < len(items); i++ {
+		dst = f.addVal(dst, &items[i])
+	}
+	if len(items) > 0 {
+		dst = dst[:len(dst)-1]
+	}
+	return append(dst, ']', ',')
+}
+
+// addValDict will append nested items as a JSON object.
+func (f formatd) addValDict(dst []byte, items []KeyValue) []byte {
+	dst = f.addKVs(append(dst, '{'), items)
+	if len(items) > 0 {
+		dst = dst[:len(dst)-1]
+	}
+	return append(dst, '}', ',')
+}
+
+// addValStack will append stack frames as an array of objects.
+func (f formatd) addValStack(dst []byte, frames []KeyValue) []byte {
+	dst = append(dst, '[')
+	for i := 0; i < len(frames); i++ {
+		dst = append(dst, `{"func":`...)
+		dst = appendString(dst, frames[i].Key, true)
+		dst = append(dst, `,"file":`...)
+		dst = appendString(dst, frames[i].Vstr, true)
+		dst = append(dst, `,"line":`...)
+		dst = append(strconv.AppendInt(dst, frames[i].Vint, 10), '}', ',')
+	}
+	if len(frames) > 0 {
+		dst = dst[:len(dst)-1]
+	}
+	return append(dst, ']', ',')
+}
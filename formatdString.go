@@ -6,13 +6,14 @@ import "unicode/utf8"
 
 const hex = "0123456789abcdef"
 
-var noEscapeTable = [256]bool{}
-
-func init() {
+// noEscapeTable is initialized by a function rather than init(),
+// so package level variables such as dLayout can use it.
+var noEscapeTable = func() (t [256]bool) {
 	for i := 0; i <= 0x7e; i++ {
-		noEscapeTable[i] = i >= 0x20 && i != '\\' && i != '"'
+		t[i] = i >= 0x20 && i != '\\' && i != '"'
 	}
-}
+	return t
+}()
 
 func appendString(dst []byte, s string, addQuote bool) []byte {
 	// Start with a double quote.
@@ -95,3 +96,75 @@ func appendStringComplex(dst []byte, s string, i int) []byte {
 	}
 	return dst
 }
+
+// appendBytes is same as appendString, but for a byte slice
+// to avoid converting it to a string.
+func appendBytes(dst []byte, s []byte, addQuote bool) []byte {
+	if addQuote {
+		dst = append(dst, '"')
+	}
+	for i := 0; i < len(s); i++ {
+		if !noEscapeTable[s[i]] {
+			dst = appendBytesComplex(dst, s, i)
+			if addQuote {
+				return append(dst, '"')
+			}
+			return dst
+		}
+	}
+	dst = append(dst, s...)
+	if addQuote {
+		return append(dst, '"')
+	}
+	return dst
+}
+
+func appendBytesComplex(dst []byte, s []byte, i int) []byte {
+	start := 0
+	for i < len(s) {
+		b := s[i]
+		if b >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				if start < i {
+					dst = append(dst, s[start:i]...)
+				}
+				dst = append(dst, `\ufffd`...)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if noEscapeTable[b] {
+			i++
+			continue
+		}
+		if start < i {
+			dst = append(dst, s[start:i]...)
+		}
+		switch b {
+		case '"', '\\':
+			dst = append(dst, '\\', b)
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+		}
+		i++
+		start = i
+	}
+	if start < len(s) {
+		dst = append(dst, s[start:]...)
+	}
+	return dst
+}
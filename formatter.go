@@ -1,5 +1,7 @@
 package alog
 
+import "time"
+
 // Formatter is an interface for a combination of formatter and writer.
 type Formatter interface {
 	// Init will initialize or update the formatter setting.
@@ -10,8 +12,9 @@ type Formatter interface {
 	// Begin will be used for formats requiring prefix such as `{` in JSON.
 	Begin([]byte) []byte
 
-	// AddTime will add time to buffer.
-	AddTime([]byte) []byte
+	// AddTime will add time to buffer. The time is taken once per
+	// entry, so all outputs of the entry will have the same time.
+	AddTime([]byte, time.Time) []byte
 
 	// AddLevel will add level to buffer.
 	AddLevel([]byte, Level) []byte
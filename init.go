@@ -1,5 +1,11 @@
 package alog
 
+import (
+	"context"
+	"strings"
+	"time"
+)
+
 var dFmtChars [256]bool
 var dFmt formatd
 
@@ -55,12 +61,37 @@ func (l Level) NameShort() string {
 	}
 }
 
+// ParseLevel returns the level of a name such as "info" or "INF".
+// Names are case insensitive, and the second return value will be
+// false if the name is not a level.
+func ParseLevel(name string) (Level, bool) {
+	for l := TraceLevel; l <= FatalLevel; l++ {
+		if strings.EqualFold(name, l.Name()) || strings.EqualFold(name, l.NameShort()) {
+			return l, true
+		}
+	}
+	return 0, false
+}
+
 // LoggerFn will be used to manipulate multiple functionality at once.
 type LoggerFn func(Logger) Logger
 
 // EntryFn will
 type EntryFn func(*Entry) *Entry
 
+// CtxFn is used to pull values from a context into an Entry.
+// See Logger.WithCtxFn and Entry.Ctx.
+type CtxFn func(context.Context, *Entry) *Entry
+
+// HookFn is run by Entry.write before the entry is formatted, with its level,
+// tag, message and key values (without fields bound by Logger.With).
+// It can add fields to the entry using e, such as e.Str("host", host).
+// If it returns false, the entry will be dropped and the rest of hooks won't run.
+type HookFn func(e *Entry, level Level, tag Tag, msg string, kvs []KeyValue) bool
+
+// ClockFn returns the current time. It's used for Logger.Clock.
+type ClockFn func() time.Time
+
 // ControlFn is used to trigger whether log or not in control.
 // Once ControlFn is set, level/tag conditions will be ignored.
 type ControlFn func(Level, Tag) bool
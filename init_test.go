@@ -23,3 +23,16 @@ func TestLevel(t *testing.T) {
 	check(6, "fatal", "FTL")
 	check(7, "", "")
 }
+
+func TestParseLevel(t *testing.T) {
+	for name, exp := range map[string]alog.Level{
+		"trace": alog.TraceLevel, "INFO": alog.InfoLevel, "WRN": alog.WarnLevel, "ftl": alog.FatalLevel,
+	} {
+		if l, ok := alog.ParseLevel(name); !ok || l != exp {
+			t.Errorf("unexpected level of %s // act=<%d>", name, l)
+		}
+	}
+	if _, ok := alog.ParseLevel("loud"); ok {
+		t.Errorf("unexpected level of loud")
+	}
+}
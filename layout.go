@@ -0,0 +1,100 @@
+package alog
+
+// Field is a reserved field of the built-in formatter. It's used to
+// set the order of reserved fields with Layout.
+type Field uint8
+
+const (
+	FieldTime    Field = iota + 1 // FieldTime is for date, day, time or ts
+	FieldLevel                    // FieldLevel is for level
+	FieldTag                      // FieldTag is for tag
+	FieldCaller                   // FieldCaller is for caller and func
+	FieldMessage                  // FieldMessage is for message
+
+	fieldCount = 5
+)
+
+// Layout sets key names and the order of reserved fields for the built-in
+// formatter, without switching to a custom formatter. Empty names will use
+// the default. Fields not in Order will follow in the default order.
+// If Fields is set, user fields will be nested under the key, so they
+// won't collide with reserved keys. See Logger.SetLayout.
+//   eg. al = al.SetLayout(alog.Layout{
+//           TS: "@timestamp", Level: "severity", Message: "msg",
+//           Order: []alog.Field{alog.FieldLevel, alog.FieldTime},
+//           Fields: "fields",
+//       })
+type Layout struct {
+	Date    string // default: date
+	Day     string // default: day
+	Time    string // default: time
+	TS      string // TS is for a single timestamp such as WithUnixTime or WithRFC3339; default: ts
+	Level   string // default: level
+	Tag     string // default: tag
+	Caller  string // default: caller
+	Func    string // default: func
+	Message string // default: message
+
+	Order  []Field
+	Fields string
+}
+
+// layout is a Layout with keys pre-encoded such as `"date":`.
+type layout struct {
+	date, day, time, ts         string
+	level, tag, caller, fn, msg string
+	fields                      string // fields is `"fields":{` if set
+	order                       [fieldCount]Field
+}
+
+// dLayout is the default layout of the built-in formatter.
+var dLayout = newLayout(Layout{})
+
+// newLayout pre-encodes keys of lo.
+func newLayout(lo Layout) *layout {
+	key := func(name, def string) string {
+		if name == "" {
+			name = def
+		}
+		return string(dFmt.addKey(nil, name))
+	}
+	out := &layout{
+		date:   key(lo.Date, "date"),
+		day:    key(lo.Day, "day"),
+		time:   key(lo.Time, "time"),
+		ts:     key(lo.TS, "ts"),
+		level:  key(lo.Level, "level"),
+		tag:    key(lo.Tag, "tag"),
+		caller: key(lo.Caller, "caller"),
+		fn:     key(lo.Func, "func"),
+		msg:    key(lo.Message, "message"),
+	}
+	if lo.Fields != "" {
+		out.fields = key(lo.Fields, "") + "{"
+	}
+
+	// Fields in Order first, and then the rest in the default order.
+	n := 0
+	var seen [fieldCount + 1]bool
+	for i := 0; i < len(lo.Order); i++ {
+		if f := lo.Order[i]; f >= FieldTime && f <= FieldMessage && !seen[f] {
+			seen[f] = true
+			out.order[n] = f
+			n++
+		}
+	}
+	for f := FieldTime; f <= FieldMessage; f++ {
+		if !seen[f] {
+			out.order[n] = f
+			n++
+		}
+	}
+	return out
+}
+
+// SetLayout will set key names and the order of reserved fields
+// for the built-in formatter. Custom formatters are not affected.
+func (l Logger) SetLayout(lo Layout) Logger {
+	l.layout = newLayout(lo)
+	return l
+}
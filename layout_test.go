@@ -0,0 +1,43 @@
+package alog_test
+
+import (
+	"bytes"
+	"github.com/gonyyi/alog"
+	"testing"
+)
+
+func TestLogger_SetLayout(t *testing.T) {
+	var out bytes.Buffer
+	al := alog.New(&out)
+	al.Flag = alog.WithLevel | alog.WithTag | alog.WithUnixTime
+	tagDB := al.NewTag("DB")
+
+	al = al.SetLayout(alog.Layout{
+		TS:      "@timestamp",
+		Level:   "severity",
+		Message: "msg",
+		Order:   []alog.Field{alog.FieldMessage, alog.FieldLevel},
+		Fields:  "fields",
+	})
+	al.Info(tagDB).Int("level", 1).Writes("hello")
+	exp := `{"msg":"hello","severity":"info","@timestamp":`
+	if !bytes.HasPrefix(out.Bytes(), []byte(exp)) || !bytes.HasSuffix(out.Bytes(), []byte(`,"tag":["DB"],"fields":{"level":1}}`+"\n")) {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+
+	// Without user fields, "fields" won't be added.
+	out.Reset()
+	al.Flag = alog.WithLevel
+	al.Info().Writes("hello")
+	if exp := `{"msg":"hello","severity":"info"}` + "\n"; out.String() != exp {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+
+	// Bound fields are user fields as well.
+	out.Reset()
+	al = al.With(func(e *alog.Entry) *alog.Entry { return e.Str("svc", "api") })
+	al.Info().Write()
+	if exp := `{"severity":"info","fields":{"svc":"api"}}` + "\n"; out.String() != exp {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"github.com/gonyyi/alog"
 	"io"
 	"os"
@@ -52,3 +53,39 @@ func Error(t ...alog.Tag) *alog.Entry {
 func Fatal(t ...alog.Tag) *alog.Entry {
 	return al.Fatal(t...)
 }
+
+// ctxLogger returns a logger stored in ctx by alog.NewContext,
+// or the default logger if not found.
+func ctxLogger(ctx context.Context) alog.Logger {
+	return alog.FromContext(ctx, al)
+}
+
+func TraceCtx(ctx context.Context, t ...alog.Tag) *alog.Entry {
+	l := ctxLogger(ctx)
+	return l.Trace(t...).Ctx(ctx)
+}
+
+func DebugCtx(ctx context.Context, t ...alog.Tag) *alog.Entry {
+	l := ctxLogger(ctx)
+	return l.Debug(t...).Ctx(ctx)
+}
+
+func InfoCtx(ctx context.Context, t ...alog.Tag) *alog.Entry {
+	l := ctxLogger(ctx)
+	return l.Info(t...).Ctx(ctx)
+}
+
+func WarnCtx(ctx context.Context, t ...alog.Tag) *alog.Entry {
+	l := ctxLogger(ctx)
+	return l.Warn(t...).Ctx(ctx)
+}
+
+func ErrorCtx(ctx context.Context, t ...alog.Tag) *alog.Entry {
+	l := ctxLogger(ctx)
+	return l.Error(t...).Ctx(ctx)
+}
+
+func FatalCtx(ctx context.Context, t ...alog.Tag) *alog.Entry {
+	l := ctxLogger(ctx)
+	return l.Fatal(t...).Ctx(ctx)
+}
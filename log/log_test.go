@@ -1,6 +1,9 @@
 package log_test
 
 import (
+	"bytes"
+	"context"
+	"github.com/gonyyi/alog"
 	"github.com/gonyyi/alog/log"
 	"testing"
 )
@@ -10,3 +13,26 @@ func TestLog(t *testing.T) {
 	//log.Info().write()
 	log.Info().Writes("")
 }
+
+func TestInfoCtx(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.Flag(alog.WithLevel)
+
+	ctx := alog.ContextWith(context.Background(), func(e *alog.Entry) *alog.Entry {
+		return e.Str("request_id", "r1")
+	})
+	log.InfoCtx(ctx).Writes("done")
+	if exp := `{"level":"info","message":"done","request_id":"r1"}` + "\n"; out.String() != exp {
+		t.Errorf("InfoCtx // exp=<%s>, act=<%s>", exp, out.String())
+	}
+	out.Reset()
+
+	// Logger stored in the context will be used.
+	var out2 bytes.Buffer
+	ctx = alog.NewContext(ctx, alog.New(&out2))
+	log.WarnCtx(ctx).Writes("done")
+	if out.Len() != 0 || !bytes.Contains(out2.Bytes(), []byte(`"message":"done","request_id":"r1"`)) {
+		t.Errorf("WarnCtx // out=<%s>, out2=<%s>", out.String(), out2.String())
+	}
+}
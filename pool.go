@@ -5,6 +5,9 @@ import "sync"
 const(
 	entry_buf_size = 1024
 	entry_kv_size = 10
+	entry_sub_size = 32
+	entry_stack_size = 32
+	entry_seg_size = 4
 )
 
 var pool = sync.Pool {
@@ -12,6 +15,7 @@ var pool = sync.Pool {
 		return &Entry{
 			buf: make([]byte, entry_buf_size),
 			kvs: make([]KeyValue, entry_kv_size),
+			sub: make([]KeyValue, 0, entry_sub_size),
 		}
 	},
 }
@@ -25,5 +29,6 @@ func get(info entryInfo) *Entry {
 func put(b *Entry) {
 	b.buf = b.buf[:0]
 	b.kvs = b.kvs[:0]
+	b.sub = b.sub[:0]
 	pool.Put(b)
 }
\ No newline at end of file
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package alog_test
+
+// raceEnabled is true with the race detector, where sync.Pool drops
+// items randomly, so allocation counts of pooled entries are not checked.
+const raceEnabled = true
@@ -0,0 +1,362 @@
+// Package reader parses JSON lines written by the built-in formatter of alog
+// back into records.
+//   eg. rd := reader.New(f, reader.Config{})
+//       for {
+//           rec, err := rd.Read()
+//           if err == io.EOF {
+//               break
+//           }
+//           if le, ok := err.(*reader.LineError); ok {
+//               fmt.Fprintln(os.Stderr, le) // a corrupt line; keep going
+//               continue
+//           }
+//           if err != nil {
+//               return err
+//           }
+//           fmt.Println(rec.Time, rec.Level.Name(), rec.Tags, rec.Message)
+//       }
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gonyyi/alog"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ErrTruncated is for a line ending in the middle of an entry,
+	// such as the last line written by a process crashed.
+	ErrTruncated = alog.Err("reader: truncated line")
+	// ErrCorrupt is for a line which is not a JSON object.
+	ErrCorrupt = alog.Err("reader: corrupt line")
+	// ErrTooLong is for a line longer than Config.MaxLine.
+	ErrTooLong = alog.Err("reader: line too long")
+	// ErrTime is for a line with a time which can't be parsed. The
+	// record is returned with it, but without the time.
+	ErrTime = alog.Err("reader: invalid time")
+)
+
+// LineError is returned by Reader.Read for a line which can't be parsed.
+// Reading can continue with the next line.
+type LineError struct {
+	Line int    // Line is the line number starting from 1
+	Text string // Text is the beginning of the line
+	Err  error  // Err is ErrTruncated, ErrCorrupt, ErrTooLong or ErrTime
+}
+
+func (e *LineError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Err.Error() + ": " + strconv.Quote(e.Text)
+}
+
+// Unwrap returns Err, so errors.Is(err, reader.ErrTruncated) can be used.
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Config is a setting for a reader.
+type Config struct {
+	// Layout is key names used by Logger.SetLayout, if changed.
+	Layout alog.Layout
+
+	// Location is the time zone of `date` and `time`; default is time.Local.
+	// For the time written with WithUTC, use time.UTC.
+	Location *time.Location
+
+	// TimeMs tells `time` is written with WithTimeMs such as 203337123.
+	// If false, a time larger than 235959 will still be read as with
+	// milliseconds, but a time like 1000 (00:00:01.000) can't be told.
+	TimeMs bool
+
+	// MaxLine is the maximum length of a line; default is 1MB.
+	MaxLine int
+}
+
+// Record is an entry read.
+type Record struct {
+	Line    int // Line is the line number starting from 1
+	Time    time.Time
+	Level   alog.Level // Level is 0 if not found
+	Tags    []string
+	Message string
+	Fields  []alog.KeyValue // Fields are all other keys including caller and func
+	Raw     []byte          // Raw is the line without a newline
+}
+
+// HasTag returns true if the record has the tag name.
+func (r Record) HasTag(name string) bool {
+	for i := 0; i < len(r.Tags); i++ {
+		if r.Tags[i] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the first field of the key. Fields in a dict can be found
+// with dot notation such as "http.method".
+func (r Record) Field(key string) (alog.KeyValue, bool) {
+	for i := 0; i < len(r.Fields); i++ {
+		if r.Fields[i].Key == key {
+			return r.Fields[i], true
+		}
+	}
+	for i := 0; i < len(r.Fields); i++ {
+		if kv := r.Fields[i]; kv.Vtype == alog.KvDict && strings.HasPrefix(key, kv.Key+".") {
+			if f, ok := (Record{Fields: kv.Vkvs}).Field(key[len(kv.Key)+1:]); ok {
+				return f, true
+			}
+		}
+	}
+	return alog.KeyValue{}, false
+}
+
+// New returns a reader of alog JSON lines from r.
+func New(r io.Reader, cfg Config) *Reader {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.MaxLine < 1 {
+		cfg.MaxLine = 1 << 20
+	}
+	name := func(name, def string) string {
+		if name == "" {
+			return def
+		}
+		return name
+	}
+	lo := cfg.Layout
+	return &Reader{
+		r:   bufio.NewReaderSize(r, 64<<10),
+		cfg: cfg,
+		keys: [keyCount]string{
+			keyDate:   name(lo.Date, "date"),
+			keyDay:    name(lo.Day, "day"),
+			keyTime:   name(lo.Time, "time"),
+			keyTS:     name(lo.TS, "ts"),
+			keyLevel:  name(lo.Level, "level"),
+			keyTag:    name(lo.Tag, "tag"),
+			keyMsg:    name(lo.Message, "message"),
+			keyFields: lo.Fields,
+		},
+	}
+}
+
+// Reserved keys of a record.
+const (
+	keyDate = iota
+	keyDay
+	keyTime
+	keyTS
+	keyLevel
+	keyTag
+	keyMsg
+	keyFields
+	keyCount
+)
+
+// Reader reads records from alog JSON lines.
+type Reader struct {
+	r    *bufio.Reader
+	cfg  Config
+	keys [keyCount]string
+	line int
+	buf  []byte
+}
+
+// Read returns the next record. For a line which can't be parsed,
+// it returns *LineError, and the next Read will continue with
+// the next line. For ErrTime, the record is returned as well.
+// Empty lines are skipped. At the end, io.EOF will be returned.
+func (r *Reader) Read() (Record, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return Record{}, err
+		}
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+		return r.parse(line)
+	}
+}
+
+// readLine returns the next line without a newline. The last line
+// without a newline will be returned as well.
+func (r *Reader) readLine() ([]byte, error) {
+	r.buf = r.buf[:0]
+	tooLong := false
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if len(r.buf)+len(chunk) > r.cfg.MaxLine {
+			tooLong = true
+		}
+		if !tooLong {
+			r.buf = append(r.buf, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && (err != io.EOF || len(chunk) == 0 && len(r.buf) == 0 && !tooLong) {
+			return nil, err
+		}
+		r.line++
+		if tooLong {
+			return nil, r.lineError(r.buf, ErrTooLong)
+		}
+		return bytes.TrimSuffix(r.buf, []byte{'\n'}), nil
+	}
+}
+
+// lineError returns a LineError of the current line.
+func (r *Reader) lineError(line []byte, err error) *LineError {
+	if len(line) > 64 {
+		line = line[:64]
+	}
+	return &LineError{Line: r.line, Text: string(line), Err: err}
+}
+
+// parse parses a line into a record.
+func (r *Reader) parse(line []byte) (Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	kvs, err := readObject(dec, true)
+	if err == nil {
+		// Nothing should follow the object.
+		if _, terr := dec.Token(); terr != io.EOF {
+			err = ErrCorrupt
+		}
+	}
+	if err != nil {
+		// A syntax error at the end is also from a truncated line.
+		var se *json.SyntaxError
+		if err == io.EOF || err == io.ErrUnexpectedEOF || errors.As(err, &se) && se.Offset >= int64(len(line)) {
+			err = ErrTruncated
+		} else {
+			err = ErrCorrupt
+		}
+		return Record{}, r.lineError(line, err)
+	}
+
+	rec := Record{
+		Line: r.line,
+		Raw:  append([]byte{}, line...),
+	}
+	var date, tm *alog.KeyValue
+	var seen [keyCount]bool
+	var terr error
+	for i := 0; i < len(kvs); i++ {
+		kv := &kvs[i]
+		k := r.reserved(kv, &seen)
+		switch k {
+		case keyDate:
+			date = kv
+		case keyTime:
+			tm = kv
+		case keyDay:
+			// weekday can be known from the date
+		case keyTS:
+			rec.Time, terr = r.timestamp(kv)
+		case keyLevel:
+			rec.Level, _ = alog.ParseLevel(kv.Vstr)
+		case keyTag:
+			for j := 0; j < len(kv.Vkvs); j++ {
+				rec.Tags = append(rec.Tags, kv.Vkvs[j].Vstr)
+			}
+		case keyMsg:
+			rec.Message = kv.Vstr
+		case keyFields:
+			rec.Fields = append(rec.Fields, kv.Vkvs...)
+		default:
+			rec.Fields = append(rec.Fields, *kv)
+		}
+	}
+	if date != nil || tm != nil {
+		rec.Time = r.dateTime(date, tm)
+	}
+	if terr != nil {
+		return rec, r.lineError(line, ErrTime)
+	}
+	return rec, nil
+}
+
+// reserved returns the reserved key of kv, or -1 if it's a field. Each
+// reserved key is taken once with the expected type, so a user field
+// of the same name will be kept as a field.
+func (r *Reader) reserved(kv *alog.KeyValue, seen *[keyCount]bool) int {
+	for k := 0; k < keyCount; k++ {
+		if seen[k] || r.keys[k] == "" || kv.Key != r.keys[k] {
+			continue
+		}
+		ok := false
+		switch k {
+		case keyDate, keyDay, keyTime:
+			ok = kv.Vtype == alog.KvInt
+		case keyTS:
+			ok = kv.Vtype == alog.KvInt || kv.Vtype == alog.KvString
+		case keyLevel:
+			_, ok = alog.ParseLevel(kv.Vstr)
+			ok = ok && kv.Vtype == alog.KvString
+		case keyMsg:
+			ok = kv.Vtype == alog.KvString
+		case keyTag:
+			ok = kv.Vtype == alog.KvStrs
+		case keyFields:
+			ok = kv.Vtype == alog.KvDict
+		}
+		if ok {
+			seen[k] = true
+			return k
+		}
+	}
+	return -1
+}
+
+// timestamp returns the time of `ts`; RFC 3339 or unix time
+// in seconds, milliseconds, microseconds or nanoseconds.
+func (r *Reader) timestamp(kv *alog.KeyValue) (time.Time, error) {
+	if kv.Vtype == alog.KvString {
+		t, err := time.Parse(time.RFC3339Nano, kv.Vstr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.In(r.cfg.Location), nil
+	}
+	// Unit is told by the size; 1e11 seconds is in year 5138.
+	var t time.Time
+	switch ts := kv.Vint; {
+	case ts < 1e11 && ts > -1e11:
+		t = time.Unix(ts, 0)
+	case ts < 1e14 && ts > -1e14:
+		t = time.Unix(0, ts*1e6)
+	case ts < 1e17 && ts > -1e17:
+		t = time.Unix(0, ts*1e3)
+	default:
+		t = time.Unix(0, ts)
+	}
+	return t.In(r.cfg.Location), nil
+}
+
+// dateTime returns the time of `date` such as 20210308
+// and `time` such as 203337 or 203337123.
+func (r *Reader) dateTime(date, tm *alog.KeyValue) time.Time {
+	y, mo, d := 1, 1, 1
+	if date != nil {
+		v := int(date.Vint)
+		y, mo, d = v/10000, v/100%100, v%100
+	}
+	h, mi, s, ms := 0, 0, 0, 0
+	if tm != nil {
+		v := int(tm.Vint)
+		if r.cfg.TimeMs || v > 235959 {
+			v, ms = v/1000, v%1000
+		}
+		h, mi, s = v/10000, v/100%100, v%100
+	}
+	return time.Date(y, time.Month(mo), d, h, mi, s, ms*1e6, r.cfg.Location)
+}
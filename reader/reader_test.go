@@ -0,0 +1,160 @@
+package reader_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/reader"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2021, 3, 8, 20, 33, 37, 123456789, time.UTC)
+
+func readAll(t *testing.T, r io.Reader, cfg reader.Config) ([]reader.Record, []*reader.LineError) {
+	t.Helper()
+	rd := reader.New(r, cfg)
+	var recs []reader.Record
+	var errs []*reader.LineError
+	for {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			return recs, errs
+		}
+		var le *reader.LineError
+		if errors.As(err, &le) {
+			errs = append(errs, le)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func TestReader(t *testing.T) {
+	var out bytes.Buffer
+	al := alog.New(&out)
+	al.Clock = func() time.Time { return now }
+	al.Location = time.UTC
+	al.Control.Level = alog.TraceLevel
+	tagDB := al.NewTag("DB")
+	tagNet := al.NewTag("Net")
+
+	al.Flag = alog.WithDate | alog.WithTime | alog.WithLevel | alog.WithTag
+	al.Error(tagDB, tagNet).Int("status", 500).Uint64("big", math.MaxUint64).Float("ratio", 0.5).
+		Bool("ok", false).Err(errors.New("timeout")).Strs("names", []string{"a", "b"}).
+		Floats("fs", []float64{1, 1.5}).Dict("req", func(e *alog.Entry) { e.Str("method", "GET") }).
+		Writes("query failed")
+	al.Flag = alog.WithDate | alog.WithTimeMs | alog.WithLevel
+	al.Info().Writes("ms")
+	al.Flag = alog.WithRFC3339Nano | alog.WithLevel
+	al.Warn().Writes("rfc3339")
+	al.Flag = alog.WithUnixTimeUs
+	al.Debug().Str("level", "not a level").Writes("unix")
+
+	recs, errs := readAll(t, &out, reader.Config{Location: time.UTC})
+	if len(recs) != 4 || len(errs) != 0 {
+		t.Fatalf("unexpected records: %d, errors: %v", len(recs), errs)
+	}
+
+	r := recs[0]
+	if !r.Time.Equal(now.Truncate(time.Second)) || r.Level != alog.ErrorLevel || r.Message != "query failed" ||
+		strings.Join(r.Tags, ",") != "DB,Net" || !r.HasTag("Net") || r.Line != 1 {
+		t.Errorf("unexpected record: %+v", r)
+	}
+	for _, tc := range []struct {
+		key   string
+		vtype alog.KvType
+	}{
+		{"status", alog.KvInt}, {"big", alog.KvUint}, {"ratio", alog.KvFloat64}, {"ok", alog.KvBool},
+		{"error", alog.KvString}, {"names", alog.KvStrs}, {"fs", alog.KvFloats}, {"req.method", alog.KvString},
+	} {
+		if kv, ok := r.Field(tc.key); !ok || kv.Vtype != tc.vtype {
+			t.Errorf("unexpected field %s: %+v", tc.key, kv)
+		}
+	}
+	if kv, _ := r.Field("big"); kv.Uint() != math.MaxUint64 {
+		t.Errorf("unexpected big: %d", kv.Uint())
+	}
+
+	if !recs[1].Time.Equal(now.Truncate(time.Millisecond)) {
+		t.Errorf("unexpected time with ms: %s", recs[1].Time)
+	}
+	if !recs[2].Time.Equal(now) || recs[2].Level != alog.WarnLevel {
+		t.Errorf("unexpected rfc3339 record: %+v", recs[2])
+	}
+	// A user field of a reserved name, but not a level, will be a field.
+	if r := recs[3]; !r.Time.Equal(now.Truncate(time.Microsecond)) || r.Level != 0 || len(r.Fields) != 1 {
+		t.Errorf("unexpected unix record: %+v", r)
+	}
+}
+
+func TestReader_Layout(t *testing.T) {
+	var out bytes.Buffer
+	lo := alog.Layout{TS: "@timestamp", Level: "severity", Message: "msg", Fields: "fields"}
+	al := alog.New(&out).SetLayout(lo)
+	al.Clock = func() time.Time { return now }
+	al.Flag = alog.WithUnixTimeMs | alog.WithLevel
+	al.Info().Str("msg", "user field").Writes("hello")
+
+	recs, _ := readAll(t, &out, reader.Config{Layout: lo})
+	if len(recs) != 1 {
+		t.Fatalf("unexpected records: %d", len(recs))
+	}
+	r := recs[0]
+	if r.Message != "hello" || r.Level != alog.InfoLevel || !r.Time.Equal(now.Truncate(time.Millisecond)) {
+		t.Errorf("unexpected record: %+v", r)
+	}
+	if kv, ok := r.Field("msg"); !ok || kv.Vstr != "user field" {
+		t.Errorf("unexpected field: %+v", r.Fields)
+	}
+}
+
+func TestReader_Corrupt(t *testing.T) {
+	in := `{"level":"info","message":"1"}` + "\n" +
+		`{"level":"info","mess` + "\n" +
+		`{"level":"info","count":12` + "\n" +
+		`not json` + "\n" +
+		`{"level":"info"} trailing` + "\n" +
+		`{"level":"info","message":"` + strings.Repeat("x", 200) + `"}` + "\n" +
+		`{"level":"info","message":"2"}` // no newline at the end
+
+	recs, errs := readAll(t, strings.NewReader(in), reader.Config{MaxLine: 100})
+	if len(recs) != 2 || recs[0].Message != "1" || recs[1].Message != "2" || recs[1].Line != 7 {
+		t.Errorf("unexpected records: %+v", recs)
+	}
+	exp := []struct {
+		line int
+		err  error
+	}{{2, reader.ErrTruncated}, {3, reader.ErrTruncated}, {4, reader.ErrCorrupt}, {5, reader.ErrCorrupt}, {6, reader.ErrTooLong}}
+	if len(errs) != len(exp) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	for i, e := range exp {
+		if errs[i].Line != e.line || !errors.Is(errs[i], e.err) {
+			t.Errorf("unexpected error: %v", errs[i])
+		}
+	}
+}
+
+func TestReader_Timestamp(t *testing.T) {
+	kst := time.FixedZone("KST", 9*3600)
+	in := `{"ts":"2021-03-08T20:33:37Z","message":"1"}` + "\n" +
+		`{"ts":"yesterday","message":"2"}` + "\n"
+	rd := reader.New(strings.NewReader(in), reader.Config{Location: kst})
+
+	rec, err := rd.Read()
+	if err != nil || !rec.Time.Equal(now.Truncate(time.Second)) || rec.Time.Location() != kst {
+		t.Errorf("unexpected record: %+v, %v", rec, err)
+	}
+	// A time which can't be parsed is reported with the record.
+	rec, err = rd.Read()
+	if !errors.Is(err, reader.ErrTime) || rec.Message != "2" || !rec.Time.IsZero() {
+		t.Errorf("unexpected record: %+v, %v", rec, err)
+	}
+}
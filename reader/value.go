@@ -0,0 +1,198 @@
+package reader
+
+import (
+	"encoding/json"
+	"github.com/gonyyi/alog"
+	"strconv"
+	"strings"
+)
+
+// readObject reads items of an object as KeyValues in order. If begin
+// is true, the opening `{` will be read first.
+func readObject(dec *json.Decoder, begin bool) ([]alog.KeyValue, error) {
+	if begin {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if tok != json.Delim('{') {
+			return nil, ErrCorrupt
+		}
+	}
+	var out []alog.KeyValue
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, ErrCorrupt
+		}
+		kv, err := readValue(dec, key)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, kv)
+	}
+	// closing `}`
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readValue reads a value as a KeyValue.
+func readValue(dec *json.Decoder, key string) (alog.KeyValue, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return alog.KeyValue{}, err
+	}
+	kv := alog.KeyValue{Key: key}
+	switch v := tok.(type) {
+	case string:
+		kv.Vtype, kv.Vstr = alog.KvString, v
+	case bool:
+		kv.Vtype, kv.Vbool = alog.KvBool, v
+	case json.Number:
+		setNumber(&kv, string(v))
+	case nil:
+		kv.Vtype = alog.KvAny
+	case json.Delim:
+		if v == '{' {
+			kv.Vtype = alog.KvDict
+			kv.Vkvs, err = readObject(dec, false)
+			return kv, err
+		}
+		var items []alog.KeyValue
+		for dec.More() {
+			item, err := readValue(dec, "")
+			if err != nil {
+				return kv, err
+			}
+			items = append(items, item)
+		}
+		// closing `]`
+		if _, err := dec.Token(); err != nil {
+			return kv, err
+		}
+		setArray(&kv, items)
+	}
+	return kv, nil
+}
+
+// setNumber sets a number as KvInt, KvUint if too large for KvInt,
+// or KvFloat64.
+func setNumber(kv *alog.KeyValue, s string) {
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			kv.Vtype, kv.Vint = alog.KvInt, i
+			return
+		}
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			kv.Vtype, kv.Vint = alog.KvUint, int64(u)
+			return
+		}
+	}
+	kv.Vtype = alog.KvFloat64
+	kv.Vf64, _ = strconv.ParseFloat(s, 64)
+}
+
+// setArray sets items as an array type such as KvStrs when all items are
+// the same type; KvFloats for a mix of integers and floats, and KvStack for
+// objects of func, file and line. Otherwise, it will be KvAny of []interface{}.
+func setArray(kv *alog.KeyValue, items []alog.KeyValue) {
+	if len(items) == 0 {
+		kv.Vtype = alog.KvStrs
+		return
+	}
+	kv.Vkvs = items
+	var str, num, flt, bl, stack int
+	for i := 0; i < len(items); i++ {
+		switch items[i].Vtype {
+		case alog.KvString:
+			str++
+		case alog.KvInt:
+			num++
+		case alog.KvFloat64:
+			num++
+			flt++
+		case alog.KvBool:
+			bl++
+		case alog.KvDict:
+			if isFrame(items[i].Vkvs) {
+				stack++
+			}
+		}
+	}
+	switch len(items) {
+	case str:
+		kv.Vtype = alog.KvStrs
+	case bl:
+		kv.Vtype = alog.KvBools
+	case num:
+		if flt == 0 {
+			kv.Vtype = alog.KvInts
+			return
+		}
+		kv.Vtype = alog.KvFloats
+		for i := 0; i < len(items); i++ {
+			if items[i].Vtype == alog.KvInt {
+				items[i].Vtype, items[i].Vf64 = alog.KvFloat64, float64(items[i].Vint)
+			}
+		}
+	case stack:
+		// frames are stored as func in Key, file in Vstr and line in Vint.
+		kv.Vtype = alog.KvStack
+		for i := 0; i < len(items); i++ {
+			f := items[i].Vkvs
+			items[i] = alog.KeyValue{Key: f[0].Vstr, Vstr: f[1].Vstr, Vint: f[2].Vint}
+		}
+	default:
+		kv.Vtype, kv.Vkvs = alog.KvAny, nil
+		arr := make([]interface{}, len(items))
+		for i := 0; i < len(items); i++ {
+			arr[i] = toInterface(items[i])
+		}
+		kv.Vany = arr
+	}
+}
+
+// isFrame returns true if kvs is a stack frame written by
+// the built-in formatter; func, file and line in order.
+func isFrame(kvs []alog.KeyValue) bool {
+	return len(kvs) == 3 &&
+		kvs[0].Key == "func" && kvs[0].Vtype == alog.KvString &&
+		kvs[1].Key == "file" && kvs[1].Vtype == alog.KvString &&
+		kvs[2].Key == "line" && kvs[2].Vtype == alog.KvInt
+}
+
+// toInterface returns the value of a KeyValue read as a Go type.
+func toInterface(kv alog.KeyValue) interface{} {
+	switch kv.Vtype {
+	case alog.KvString:
+		return kv.Vstr
+	case alog.KvBool:
+		return kv.Vbool
+	case alog.KvInt:
+		return kv.Vint
+	case alog.KvUint:
+		return kv.Uint()
+	case alog.KvFloat64:
+		return kv.Vf64
+	case alog.KvDict:
+		m := make(map[string]interface{}, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			m[kv.Vkvs[i].Key] = toInterface(kv.Vkvs[i])
+		}
+		return m
+	case alog.KvAny:
+		return kv.Vany
+	default:
+		arr := make([]interface{}, len(kv.Vkvs))
+		for i := 0; i < len(kv.Vkvs); i++ {
+			arr[i] = toInterface(kv.Vkvs[i])
+		}
+		return arr
+	}
+}
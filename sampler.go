@@ -0,0 +1,111 @@
+package alog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SamplePolicy will log the first `First` entries in each Period, and
+// then every `Thereafter`th entry. If Thereafter is 0, entries after
+// the first ones will be dropped until the next period.
+// If Period is 0, a second will be used.
+//   eg. SamplePolicy{First: 100, Thereafter: 10}: first 100 per second then 1 in 10.
+//       SamplePolicy{Thereafter: 10}: 1 in 10.
+type SamplePolicy struct {
+	First      uint32
+	Thereafter uint32
+	Period     time.Duration
+}
+
+// sampleState holds counters of a SamplePolicy.
+type sampleState struct {
+	policy SamplePolicy
+	start  int64  // start of the current period in unix nano
+	count  uint32 // number of entries in the current period
+}
+
+func (s *sampleState) allow(now int64) bool {
+	period := int64(s.policy.Period)
+	if period <= 0 {
+		period = int64(time.Second)
+	}
+	if start := atomic.LoadInt64(&s.start); now-start >= period {
+		if atomic.CompareAndSwapInt64(&s.start, start, now) {
+			atomic.StoreUint32(&s.count, 0)
+		}
+	}
+	n := atomic.AddUint32(&s.count, 1)
+	if n <= s.policy.First {
+		return true
+	}
+	if s.policy.Thereafter == 0 {
+		return false
+	}
+	return (n-s.policy.First)%s.policy.Thereafter == 0
+}
+
+// Sampler decides whether an entry which passed the level/tag control
+// should be logged, by policies set per level and per tag.
+// When an entry has tags with a policy, the policy of the first such tag
+// will be used; otherwise, the policy of the level will be used.
+// Entries without any applicable policy are always logged.
+// Sampler is safe for concurrent use, but policies should be set
+// before it is used, as TagBucket is.
+type Sampler struct {
+	levels  [FatalLevel + 1]*sampleState
+	tags    [64]*sampleState
+	hasTags Tag
+}
+
+// NewSampler returns a new Sampler without any policy.
+//   eg. al.Control.Sampler = alog.NewSampler().
+//           SetLevel(alog.DebugLevel, alog.SamplePolicy{First: 10, Thereafter: 100}).
+//           SetTag(tagDB, alog.SamplePolicy{Thereafter: 10})
+func NewSampler() *Sampler {
+	return &Sampler{}
+}
+
+// SetLevel sets the policy for the level.
+func (s *Sampler) SetLevel(level Level, p SamplePolicy) *Sampler {
+	if int(level) < len(s.levels) {
+		s.levels[level] = &sampleState{policy: p}
+	}
+	return s
+}
+
+// SetTag sets the policy for each tag given.
+func (s *Sampler) SetTag(tag Tag, p SamplePolicy) *Sampler {
+	for i := 0; i < len(s.tags); i++ {
+		if tag&(1<<i) != 0 {
+			s.tags[i] = &sampleState{policy: p}
+			s.hasTags |= 1 << i
+		}
+	}
+	return s
+}
+
+// Check returns true if the entry with given level and tag should be logged.
+// Each call will be counted by the policy used.
+func (s *Sampler) Check(level Level, tag Tag) bool {
+	return s.CheckAt(level, tag, time.Now())
+}
+
+// CheckAt is Check with the time of the entry, such as from Logger.Clock,
+// which decides the period of the policy.
+func (s *Sampler) CheckAt(level Level, tag Tag, t time.Time) bool {
+	var st *sampleState
+	if t := tag & s.hasTags; t != 0 {
+		for i := 0; i < len(s.tags); i++ {
+			if t&(1<<i) != 0 {
+				st = s.tags[i]
+				break
+			}
+		}
+	} else if int(level) < len(s.levels) {
+		st = s.levels[level]
+	}
+	if st == nil {
+		return true
+	}
+	return st.allow(t.UnixNano())
+}
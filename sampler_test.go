@@ -0,0 +1,94 @@
+package alog_test
+
+import (
+	"bytes"
+	"github.com/gonyyi/alog"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	var buf bytes.Buffer
+	l := alog.New(&buf)
+	l.Flag = alog.WithLevel
+	l.Control.Level = alog.TraceLevel
+	tagDB := l.NewTag("DB")
+	tagIO := l.NewTag("IO")
+
+	l.Control.Sampler = alog.NewSampler().
+		SetLevel(alog.DebugLevel, alog.SamplePolicy{First: 2, Thereafter: 3, Period: time.Hour}).
+		SetLevel(alog.TraceLevel, alog.SamplePolicy{Period: time.Hour}).
+		SetTag(tagDB, alog.SamplePolicy{Thereafter: 2, Period: time.Hour})
+
+	count := func(fn func()) int {
+		buf.Reset()
+		for i := 0; i < 10; i++ {
+			fn()
+		}
+		return bytes.Count(buf.Bytes(), []byte("\n"))
+	}
+
+	// first 2, then 1 in 3: 1,2,5,8
+	if n := count(func() { l.Debug().Write() }); n != 4 {
+		t.Errorf("Sampler debug // exp=4, act=%d", n)
+	}
+	// everything dropped
+	if n := count(func() { l.Trace().Write() }); n != 0 {
+		t.Errorf("Sampler trace // exp=0, act=%d", n)
+	}
+	// dropped entries are not fetched from the pool
+	if e := l.Trace(); e != nil {
+		t.Errorf("Sampler trace // exp=nil entry")
+	}
+	// no policy for info
+	if n := count(func() { l.Info().Write() }); n != 10 {
+		t.Errorf("Sampler info // exp=10, act=%d", n)
+	}
+	// tag policy has precedence over level policy: 1 in 2
+	if n := count(func() { l.Trace(tagDB).Write() }); n != 5 {
+		t.Errorf("Sampler tag // exp=5, act=%d", n)
+	}
+	if n := count(func() { l.Info(tagIO).Write() }); n != 10 {
+		t.Errorf("Sampler tag2 // exp=10, act=%d", n)
+	}
+
+	// Not loggable entries should not be counted.
+	l.Control.Level = alog.InfoLevel
+	l.Control.Sampler = alog.NewSampler().SetLevel(alog.DebugLevel, alog.SamplePolicy{First: 1, Period: time.Hour})
+	l.Debug().Write()
+	l.Control.Level = alog.DebugLevel
+	if n := count(func() { l.Debug().Write() }); n != 1 {
+		t.Errorf("Sampler not loggable // exp=1, act=%d", n)
+	}
+
+	// Control.Fn is consulted before the sampler.
+	l.Control.Fn = func(alog.Level, alog.Tag) bool { return true }
+	if n := count(func() { l.Debug().Write() }); n != 0 {
+		t.Errorf("Sampler with Fn // exp=0, act=%d", n)
+	}
+	if l.Control.Sample(alog.DebugLevel, 0) {
+		t.Errorf("control.Sample() should return false")
+	}
+
+	// Periods are by Logger.Clock.
+	l.Control.Fn = nil
+	now := time.Date(2021, 3, 8, 20, 33, 37, 0, time.UTC)
+	l.Clock = func() time.Time { return now }
+	l.Control.Sampler = alog.NewSampler().SetLevel(alog.DebugLevel, alog.SamplePolicy{First: 1, Period: time.Minute})
+	if n := count(func() { l.Debug().Write() }); n != 1 {
+		t.Errorf("Sampler clock // exp=1, act=%d", n)
+	}
+	now = now.Add(time.Minute)
+	if n := count(func() { l.Debug().Write() }); n != 1 {
+		t.Errorf("Sampler clock next period // exp=1, act=%d", n)
+	}
+	l.Clock = nil
+
+	// Dropped entries should not allocate.
+	l.Control.Fn = nil
+	if n := testing.AllocsPerRun(100, func() {
+		l.Debug().Str("a", "b").Write()
+	}); n != 0 {
+		t.Errorf("unexpected allocation: %f", n)
+	}
+}
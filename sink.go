@@ -0,0 +1,96 @@
+package alog
+
+import "io"
+
+// Sink is an additional output of a Logger with its own formatter,
+// flag and level/tag filter. A sink without a formatter will use
+// the built-in formatter. Entries of sinks using the built-in formatter
+// with the same flag (including the logger's own output) will be
+// formatted only once. See Logger.AddSink.
+type Sink struct {
+	w    Writer
+	fmtr Formatter
+
+	// Flag is the format flag of the sink.
+	Flag Flag
+
+	// Level, Tags and Fn work the same way as the logger's Control.
+	// Once Fn is set, Level and Tags will be ignored.
+	Level Level
+	Tags  Tag
+	Fn    ControlFn
+}
+
+// NewSink returns a Sink writing to w with the default flag and
+// the info level. If f is nil, the built-in formatter will be used,
+// otherwise, f will be initialized with w when added to a logger.
+//   eg. s := alog.NewSink(file, nil)
+//       s.Level = alog.DebugLevel
+//       al = al.AddSink(s)
+func NewSink(w io.Writer, f Formatter) Sink {
+	s := Sink{
+		w:     Discard{},
+		fmtr:  f,
+		Flag:  WithDefault,
+		Level: InfoLevel,
+	}
+	if w != nil {
+		s.w = iowToAlw(w)
+	}
+	return s
+}
+
+// check will check if level and tag given is good to be written to the sink.
+func (s *Sink) check(lvl Level, tag Tag) bool {
+	if s.Fn != nil {
+		return s.Fn(lvl, tag)
+	}
+	return s.Level <= lvl || s.Tags&tag != 0
+}
+
+// Close will close the writer of the sink.
+func (s Sink) Close() error {
+	if s.fmtr != nil {
+		return s.fmtr.Close()
+	}
+	if s.w != nil {
+		return s.w.Close()
+	}
+	return nil
+}
+
+// AddSink will return a new Logger with sinks added. An entry will be
+// logged when either the logger's Control or any of sinks' filters allows it,
+// and it will be written only to the outputs allowing it. To use sinks only,
+// create the logger with a nil writer.
+// Note: as formatters of sinks are initialized here, set the flags of sinks
+// and tags of the logger before this.
+func (l Logger) AddSink(sinks ...Sink) Logger {
+	if len(sinks) == 0 {
+		return l
+	}
+	ss := make([]Sink, 0, len(l.sinks)+len(sinks))
+	ss = append(ss, l.sinks...)
+	for i := 0; i < len(sinks); i++ {
+		if sinks[i].w == nil {
+			sinks[i].w = Discard{}
+		}
+		if sinks[i].fmtr != nil {
+			sinks[i].fmtr.Init(sinks[i].w, sinks[i].Flag, l.Control.bucket)
+		}
+		l.sinkFlag |= sinks[i].Flag
+		ss = append(ss, sinks[i])
+	}
+	l.sinks = ss
+	return l
+}
+
+// checkSinks returns true if any of sinks allows the level and tag.
+func (l *Logger) checkSinks(lvl Level, tag Tag) bool {
+	for i := 0; i < len(l.sinks); i++ {
+		if l.sinks[i].check(lvl, tag) {
+			return true
+		}
+	}
+	return false
+}
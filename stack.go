@@ -0,0 +1,51 @@
+package alog
+
+import "runtime"
+
+// Stack adds the stack trace of the current goroutine as a KvStack item.
+// Each frame is a KeyValue of function name (Key), file (Vstr) and line (Vint).
+// To attach the stack automatically, see Logger.StackLevel.
+func (e *Entry) Stack() *Entry {
+	if e != nil {
+		e.addStack(1)
+	}
+	return e
+}
+
+// addStack captures the stack and adds it to kvs. skip is the number of
+// frames between addStack and the user's code.
+func (e *Entry) addStack(skip int) {
+	// 0: runtime.Callers, 1: addStack
+	n := runtime.Callers(2+skip, e.pcs[:])
+	if n == 0 {
+		return
+	}
+	start := len(e.sub)
+	frames := runtime.CallersFrames(e.pcs[:n])
+	for {
+		f, more := frames.Next()
+		e.sub = append(e.sub, KeyValue{
+			Key:  f.Function,
+			Vstr: f.File,
+			Vint: int64(f.Line),
+		})
+		if !more {
+			break
+		}
+	}
+	e.kvs = append(e.kvs, KeyValue{
+		Key:   "stack",
+		Vtype: KvStack,
+		Vkvs:  e.subFrom(start),
+	})
+}
+
+// hasStack returns true if a KvStack item already exists in kvs.
+func (e *Entry) hasStack() bool {
+	for i := len(e.kvs) - 1; i >= 0; i-- {
+		if e.kvs[i].Vtype == KvStack {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package alog_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gonyyi/alog"
+	"github.com/gonyyi/alog/ext"
+	"strings"
+	"testing"
+)
+
+func TestEntry_Stack(t *testing.T) {
+	reset()
+	a := struct {
+		Message string `json:"message"`
+		Stack   []struct {
+			Func string `json:"func"`
+			File string `json:"file"`
+			Line int    `json:"line"`
+		} `json:"stack"`
+	}{}
+
+	// Entry.Stack()
+	log.Info(0).Stack().Writes("done")
+	if err := json.Unmarshal(out.Bytes(), &a); err != nil {
+		t.Fatalf("unexpected json: %s // %s", err, out.String())
+	}
+	if a.Message != "done" || len(a.Stack) == 0 ||
+		a.Stack[0].Func != "github.com/gonyyi/alog_test.TestEntry_Stack" || a.Stack[0].Line < 1 {
+		t.Errorf("unexpected stack: %s", out.String())
+	}
+	out.Reset()
+
+	// StackLevel
+	log.StackLevel = alog.ErrorLevel
+	log.Warn(0).Writes("no stack")
+	if strings.Contains(out.String(), `"stack"`) {
+		t.Errorf("unexpected stack for warn: %s", out.String())
+	}
+	out.Reset()
+
+	log.Error(0).Err(errors.New("err")).Writes("failed")
+	a.Stack = nil
+	if err := json.Unmarshal(out.Bytes(), &a); err != nil {
+		t.Fatalf("unexpected json: %s // %s", err, out.String())
+	}
+	if len(a.Stack) == 0 || a.Stack[0].Func != "github.com/gonyyi/alog_test.TestEntry_Stack" {
+		t.Errorf("unexpected stack: %s", out.String())
+	}
+	if strings.Count(out.String(), `"stack"`) != 1 {
+		t.Errorf("expected only one stack: %s", out.String())
+	}
+	out.Reset()
+
+	// Terminal formatter shows the stack as an indented block
+	log = log.Ext(ext.LogFmt.Text())
+	log.Error(0).Int("a", 1).Writes("failed")
+	if s := out.String(); !strings.HasPrefix(s, "ERR [] failed // a=1\n\tgithub.com/gonyyi/alog_test.TestEntry_Stack\n\t\t") ||
+		!strings.HasSuffix(s, "\n") || strings.HasSuffix(s, "\n\n") {
+		t.Errorf("unexpected text stack: %q", s)
+	}
+	out.Reset()
+	log.StackLevel = 0
+	reset()
+}
@@ -56,6 +56,17 @@ func (t *TagBucket) MustGetTag(name string) Tag {
 	return 1 << tag
 }
 
+// Name returns the name of a single tag. If the tag has more than
+// one tag, the name of the first one will be returned.
+func (t *TagBucket) Name(tag Tag) string {
+	for i := 0; i < t.count; i++ {
+		if tag&(1<<i) != 0 {
+			return t.names[i]
+		}
+	}
+	return ""
+}
+
 func (t *TagBucket) AppendTag(dst []byte, tag Tag) []byte {
 	origLen := len(dst)
 	for i := 0; i < t.count; i++ {
@@ -134,3 +134,12 @@ func TestTagBucket_AppendTag(t *testing.T) {
 		t.Errorf("TagBucket.AppendTag() 6 // out=%s", string(out))
 	}
 }
+
+func TestTagBucket_Name(t *testing.T) {
+	b := alog.TagBucket{}
+	t1 := b.MustGetTag("T1")
+	t2 := b.MustGetTag("T2")
+	if b.Name(t1) != "T1" || b.Name(t2) != "T2" || b.Name(t1|t2) != "T1" || b.Name(0) != "" {
+		t.Errorf("TagBucket.Name()")
+	}
+}